@@ -0,0 +1,63 @@
+"""
+按文件内容哈希缓存解析结果（磁盘）
+
+键为 (解析器版本, 解析选项, 文件内容) 的 SHA-256，值为 FileAnalysis.to_json() 的输出，
+存放在 <目录>/<键前两位>/<键>.json。内容或解析器版本变化都会得到新键，旧条目不会被误用；
+损坏或版本不符的条目在读取时被忽略（由调用方重新解析并覆盖）。
+"""
+
+import hashlib
+import os
+import shutil
+import tempfile
+from pathlib import Path
+from typing import Optional, Union
+
+from .core import FileAnalysis
+
+
+class ParseCache:
+    """解析结果的磁盘缓存"""
+
+    def __init__(self, directory: Union[str, Path]):
+        self.directory = Path(directory)
+
+    @staticmethod
+    def key(content: bytes, version: str) -> str:
+        """缓存键：version 应包含解析器版本与影响输出的选项"""
+        digest = hashlib.sha256(version.encode('utf-8'))
+        digest.update(b'\0')
+        digest.update(content)
+        return digest.hexdigest()
+
+    def _path(self, key: str) -> Path:
+        return self.directory / key[:2] / f"{key}.json"
+
+    def get(self, key: str) -> Optional[FileAnalysis]:
+        """读取缓存条目；不存在、损坏或格式版本不符时返回 None"""
+        try:
+            return FileAnalysis.from_json(self._path(key).read_text(encoding='utf-8'))
+        except (OSError, ValueError, KeyError, TypeError, AttributeError):
+            return None
+
+    def put(self, key: str, analysis: FileAnalysis):
+        """写入缓存条目；先写临时文件再改名，并发写同一键也不会留下半截文件。写入失败时忽略"""
+        path = self._path(key)
+        try:
+            path.parent.mkdir(parents=True, exist_ok=True)
+            fd, tmp = tempfile.mkstemp(dir=path.parent, suffix='.tmp')
+        except OSError:
+            return
+        try:
+            with os.fdopen(fd, 'w', encoding='utf-8') as fp:
+                analysis.to_json(fp, indent=None)
+            os.replace(tmp, path)
+        except OSError:
+            try:
+                os.unlink(tmp)
+            except OSError:
+                pass
+
+    def clear(self):
+        """删除全部缓存条目"""
+        shutil.rmtree(self.directory, ignore_errors=True)
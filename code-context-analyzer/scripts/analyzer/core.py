@@ -1,16 +1,716 @@
-from dataclasses import dataclass, field
-from typing import List, Dict, Optional
+import hashlib
+import json
+import re
+from dataclasses import dataclass, field, replace
+from enum import Enum
+from typing import Any, Callable, List, Dict, Iterable, Iterator, NamedTuple, Optional, TextIO, Tuple
+
+from .tokens import count_tokens
+
+# JSON 输出格式版本。新增字段保持向后兼容时不升级；删除/重命名字段时必须升级。
+SCHEMA_VERSION = 1
+
+
+class Kind(str, Enum):
+    """符号种类（Go）
+    
+    继承 str，与其他语言解析器使用的字符串种类可以直接比较；值即 JSON 中的 kind。
+    """
+    CONST = 'const'
+    VAR = 'variable'
+    TYPE = 'type'  # 定义类型，如 type Celsius float64
+    INTERFACE = 'interface'
+    STRUCT = 'struct'
+    FUNC = 'function'
+    METHOD = 'method'
+    ALIAS = 'alias'  # 类型别名 type A = B
+    FUNC_TYPE = 'func_type'  # 函数类型 type F func(int) int
+    
+    def __str__(self) -> str:
+        return self.value
+    
+    @classmethod
+    def parse(cls, value: str):
+        """字符串转为 Kind；不是 Go 种类的字符串（如其他语言的 'class'）原样返回"""
+        try:
+            return cls(value)
+        except ValueError:
+            return value
+
+
+# 可以作为方法接收者的类型种类
+TYPE_KINDS = {Kind.TYPE, Kind.INTERFACE, Kind.STRUCT, Kind.ALIAS, Kind.FUNC_TYPE}
+
+
+def is_exported(name: str) -> bool:
+    """Go 导出规则：首字符为 Unicode 大写字母"""
+    return name[:1].isupper()
+
+
+@dataclass
+class FieldInfo:
+    """结构体字段"""
+    name: str  # 嵌入字段为空
+    type: str
+    tag: str = ""  # 原始标签内容（不含外层引号）
+    embedded: bool = False
+    line: int = 0
+    docstring: str = ""
+    comment: str = ""  # 行尾注释
+    
+    @property
+    def effective_name(self) -> str:
+        """字段的访问名：嵌入字段取类型名（去掉 *、包名与类型实参）"""
+        if self.name:
+            return self.name
+        return self.type.lstrip('*').split('[')[0].split('.')[-1]
+    
+    def to_dict(self) -> dict:
+        return {
+            'name': self.name,
+            'type': self.type,
+            'tag': self.tag,
+            'embedded': self.embedded,
+            'line': self.line,
+            'doc': self.docstring,
+            'comment': self.comment,
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'FieldInfo':
+        return cls(
+            name=data['name'],
+            type=data['type'],
+            tag=data.get('tag', ""),
+            embedded=data.get('embedded', False),
+            line=data.get('line', 0),
+            docstring=data.get('doc', ""),
+            comment=data.get('comment', ""),
+        )
+
+
+@dataclass
+class TypeParam:
+    """泛型类型参数"""
+    name: str
+    constraint: str
+    
+    def to_dict(self) -> dict:
+        return {'name': self.name, 'constraint': self.constraint}
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'TypeParam':
+        return cls(name=data['name'], constraint=data['constraint'])
+
+
+@dataclass
+class MethodSig:
+    """接口方法签名"""
+    name: str
+    parameters: List[str] = field(default_factory=list)  # 如 ["a int", "b ...string"]
+    results: List[str] = field(default_factory=list)  # 如 ["int", "error"]
+    line: int = 0
+    docstring: str = ""
+    
+    def to_dict(self) -> dict:
+        return {
+            'name': self.name,
+            'parameters': list(self.parameters),
+            'results': list(self.results),
+            'line': self.line,
+            'doc': self.docstring,
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'MethodSig':
+        return cls(
+            name=data['name'],
+            parameters=list(data.get('parameters', [])),
+            results=list(data.get('results', [])),
+            line=data.get('line', 0),
+            docstring=data.get('doc', ""),
+        )
+
 
 @dataclass
 class SymbolInfo:
     """代码符号信息"""
     name: str
-    type: str  # 'class', 'function', 'method', 'variable'
+    type: str  # Go 为 Kind；其他语言为 'class', 'function', 'method', 'variable' 等字符串
     line: int
     end_line: int = 0
     decorators: List[str] = field(default_factory=list)
     parameters: List[str] = field(default_factory=list)
     docstring: str = ""
+    receiver: str = ""  # 方法接收者类型（如 Go 的 *MyStruct）
+    column: int = 0  # 起始列（从 1 开始，0 表示未知）
+    end_column: int = 0  # 结束列，指向最后一个字符之后
+    fields: List[FieldInfo] = field(default_factory=list)  # 结构体字段
+    methods: List[MethodSig] = field(default_factory=list)  # 接口方法
+    embeds: List[str] = field(default_factory=list)  # 接口中嵌入的接口/类型约束
+    is_alias: bool = False  # 类型别名（type A = B），与定义新类型（type A B）区分
+    type_params: List[TypeParam] = field(default_factory=list)  # 泛型函数/类型的类型参数
+    signature: str = ""  # 单行规范签名，如 func (s *MyStruct) Method()
+    references: List[str] = field(default_factory=list)  # 声明中引用的标识符（选择器只记左侧），按首次出现排序
+    calls: List[str] = field(default_factory=list)  # 函数体中调用的函数（原始选择器写法，去重）
+    is_test: bool = False  # 来自测试文件（_test.go 或 package xxx_test）
+    test_kind: str = ""  # 测试函数的子类：'test'、'benchmark'、'example'、'fuzz'，其余为空
+    value: str = ""  # 常量的静态求值结果（Go 字面量写法），无法确定时为空
+    value_expr: str = ""  # 常量/变量的初始化表达式（常量含 iota 隐式重复的表达式）
+    value_type: str = ""  # 常量/变量的类型：显式写出的类型，否则从字面量推断（无类型常量取默认类型），无法推断时为空
+    source_offset: int = 0  # 原文起始偏移（字符，含文档注释）
+    source_end: int = 0  # 原文结束偏移（不含）
+    # 函数/方法的结构指纹：忽略空白、注释、函数名与局部名称的命名，用于跨仓库发现复制的函数。
+    # 与 diff.body_hash（逐字比较原文）不同，只重命名局部变量的两个函数指纹相同
+    fingerprint: str = ""
+    complexity: int = 0  # 函数/方法的圈复杂度（1 + 分支点数），其余符号为 0
+    is_deprecated: bool = False  # 文档注释含 "Deprecated:" 段落（Go 惯例）
+    deprecation_note: str = ""  # "Deprecated:" 之后到段落结束的说明，段内换行合并为空格
+    # 所在文件的路径（与 FileAnalysis.path 一致），合并多个文件的结果后仍可追溯来源；不参与比较
+    file: str = field(default="", compare=False)
+    
+    # 所在文件的完整源码，与 FileAnalysis.source 是同一个字符串对象，不额外占用内存。
+    # 不是 dataclass 字段：不参与比较，也不会被 asdict / to_dict 序列化；由 FileAnalysis.attach_source 设置
+    _source = ""
+    # 声明的 token 序列，仅在分析器开启 retain_tokens 时保留；同样不是字段，不参与比较与序列化
+    _node = None
+    
+    @property
+    def kind(self):
+        """type 的别名"""
+        return self.type
+    
+    @property
+    def key(self) -> str:
+        """在同一包内唯一的键：方法为 类型.方法名，其余为名称"""
+        return f"{receiver_base(self.receiver)}.{self.name}" if self.receiver else self.name
+    
+    def source(self, doc: bool = True) -> str:
+        """声明的原始文本，与源码逐字一致；doc 为 False 时不含文档注释
+        
+        源码不随 JSON 序列化；从 JSON 还原的符号返回空字符串。
+        """
+        text = self._source[self.source_offset:self.source_end]
+        if doc or not text or not self.line or not self.end_line:
+            return text
+        # 声明本身占最后 end_line - line + 1 行，首行从 column 开始
+        lines = text.split('\n')
+        skip = len(lines) - (self.end_line - self.line + 1)
+        if skip <= 0:
+            return text
+        return '\n'.join([lines[skip][max(self.column - 1, 0):]] + lines[skip + 1:])
+    
+    def node(self) -> Optional[Tuple[Any, ...]]:
+        """所在顶层声明的 token 序列（不含注释，Go 为 go_scanner.Token），相当于 go/ast 的声明节点
+        
+        分组声明（如 const ( ... )）中的各个符号共享整个分组的序列。位置用 token 的 offset
+        经 FileAnalysis.scanner.position() 换算为行列。只在分析器开启 retain_tokens 时可用，
+        否则（以及从 JSON 或缓存还原的符号）返回 None。
+        
+        返回的 token 与其他符号、扫描器共享，不得修改。
+        """
+        return self._node
+    
+    def source_bytes(self) -> bytes:
+        """source() 的 UTF-8 编码"""
+        return self.source().encode('utf-8')
+    
+    def context_text(self) -> str:
+        """作为上下文输入时的文本：有源码时为原文，否则为文档注释 + 签名（无签名时为 kind name）"""
+        text = self.source()
+        if text:
+            return text
+        header = self.signature or f"{self.type} {self.name}"
+        return f"{self.docstring}\n{header}" if self.docstring else header
+    
+    def token_count(self, model: str = 'cl100k') -> int:
+        """估算 context_text() 的 token 数；未知模型抛出 ValueError（见 tokens.count_tokens）"""
+        return count_tokens(self.context_text(), model)
+    
+    def to_dict(self) -> dict:
+        """转换为稳定的 JSON 结构（键名见 FileAnalysis.to_json）"""
+        return {
+            'name': self.name,
+            'kind': self.type,
+            'receiver': self.receiver,
+            'start_line': self.line,
+            'end_line': self.end_line,
+            'start_col': self.column,
+            'end_col': self.end_column,
+            'parameters': list(self.parameters),
+            'decorators': list(self.decorators),
+            'doc': self.docstring,
+            'fields': [f.to_dict() for f in self.fields],
+            'methods': [m.to_dict() for m in self.methods],
+            'embeds': list(self.embeds),
+            'is_alias': self.is_alias,
+            'type_params': [t.to_dict() for t in self.type_params],
+            'signature': self.signature,
+            'references': list(self.references),
+            'calls': list(self.calls),
+            'is_test': self.is_test,
+            'test_kind': self.test_kind,
+            'value': self.value,
+            'value_expr': self.value_expr,
+            'value_type': self.value_type,
+            'source_offset': self.source_offset,
+            'source_end': self.source_end,
+            'fingerprint': self.fingerprint,
+            'complexity': self.complexity,
+            'is_deprecated': self.is_deprecated,
+            'deprecation_note': self.deprecation_note,
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'SymbolInfo':
+        """从 to_dict 的结果还原"""
+        return cls(
+            name=data['name'],
+            type=Kind.parse(data['kind']),
+            line=data['start_line'],
+            end_line=data.get('end_line', 0),
+            decorators=list(data.get('decorators', [])),
+            parameters=list(data.get('parameters', [])),
+            docstring=data.get('doc', ""),
+            receiver=data.get('receiver', ""),
+            column=data.get('start_col', 0),
+            end_column=data.get('end_col', 0),
+            fields=[FieldInfo.from_dict(f) for f in data.get('fields', [])],
+            methods=[MethodSig.from_dict(m) for m in data.get('methods', [])],
+            embeds=list(data.get('embeds', [])),
+            is_alias=data.get('is_alias', False),
+            type_params=[TypeParam.from_dict(t) for t in data.get('type_params', [])],
+            signature=data.get('signature', ""),
+            references=list(data.get('references', [])),
+            calls=list(data.get('calls', [])),
+            is_test=data.get('is_test', False),
+            test_kind=data.get('test_kind', ""),
+            value=data.get('value', ""),
+            value_expr=data.get('value_expr', ""),
+            value_type=data.get('value_type', ""),
+            source_offset=data.get('source_offset', 0),
+            source_end=data.get('source_end', 0),
+            fingerprint=data.get('fingerprint', ""),
+            complexity=data.get('complexity', 0),
+            is_deprecated=data.get('is_deprecated', False),
+            deprecation_note=data.get('deprecation_note', ""),
+            file=data.get('file', ""),
+        )
+
+
+def _replace_symbol(sym: SymbolInfo, **changes) -> SymbolInfo:
+    """dataclasses.replace，并带上不属于字段的源码与 token"""
+    copy = replace(sym, **changes)
+    copy._source, copy._node = sym._source, sym._node
+    return copy
+
+
+def receiver_base(receiver: str) -> str:
+    """接收者的基础类型名：*MyStruct、MyStruct、*List[T] 都归到 MyStruct / List"""
+    return receiver.lstrip('*').split('[')[0].strip()
+
+
+@dataclass
+class PromotedMethod:
+    """通过嵌入字段提升到外层类型的方法"""
+    method: SymbolInfo  # 方法的原始声明
+    path: List[str] = field(default_factory=list)  # 嵌入链上的字段名，如 ['Base']
+    # 方法为指针接收者且嵌入链上没有指针时，只属于 *Outer 的方法集
+    pointer_only: bool = False
+
+
+@dataclass
+class TypeGroup:
+    """类型及其方法（见 group_by_type）"""
+    type: SymbolInfo
+    methods: List[SymbolInfo] = field(default_factory=list)
+    promoted: List[PromotedMethod] = field(default_factory=list)  # 经嵌入提升的方法，按名称排序
+
+
+def group_by_type(symbols: Iterable[SymbolInfo]) -> Tuple[Dict[str, TypeGroup], List[SymbolInfo]]:
+    """把方法挂到其接收者类型下
+    
+    返回 (类型名 -> TypeGroup, 孤立方法)。指针与值接收者归到同一基础类型；
+    接收者类型不在 symbols 中的方法进入孤立列表而不是被丢弃。
+    可以传入多个文件的符号，以处理同一包内跨文件定义的方法。
+    结构体的 promoted 列出经嵌入字段提升的方法，见 promoted_methods。
+    """
+    symbols = list(symbols)
+    groups: Dict[str, TypeGroup] = {}
+    for sym in symbols:
+        if sym.type in TYPE_KINDS and sym.name not in groups:
+            groups[sym.name] = TypeGroup(type=sym)
+    
+    orphans: List[SymbolInfo] = []
+    for sym in symbols:
+        if not sym.receiver:
+            continue
+        group = groups.get(receiver_base(sym.receiver))
+        if group is None:
+            orphans.append(sym)
+        else:
+            group.methods.append(sym)
+    for group in groups.values():
+        group.promoted = promoted_methods(group.type.name, groups)
+    return groups, orphans
+
+
+def promoted_methods(name: str, groups: Dict[str, TypeGroup]) -> List[PromotedMethod]:
+    """按 Go 的选择器规则计算类型 name 经嵌入字段提升的方法
+    
+    逐层展开嵌入字段（含指针嵌入 *T）：浅层的方法或字段遮蔽深层的同名方法，外层类型
+    自己的方法与字段遮蔽所有提升的方法；同一深度出现多个同名者时有歧义，都不提升。
+    只展开 groups 中的类型，其他包的类型与嵌入的接口不展开。
+    """
+    group = groups.get(name)
+    if group is None:
+        return []
+    blocked = {m.name for m in group.methods} | {f.effective_name for f in group.type.fields}
+    level = [(f, [f.effective_name], f.type.startswith('*')) for f in group.type.fields if f.embedded]
+    visited = {name}
+    result: List[PromotedMethod] = []
+    while level:
+        candidates: Dict[str, List[PromotedMethod]] = {}
+        field_names: Dict[str, int] = {}
+        next_level = []
+        # 同一深度经不同路径嵌入同一类型时照常展开（由此产生歧义）；只跳过更浅层已展开的类型，防止成环
+        expanded = set()
+        for embedded, path, pointer in level:
+            inner = groups.get(receiver_base(embedded.type))
+            if inner is None or inner.type.name in visited:
+                continue
+            expanded.add(inner.type.name)
+            for m in inner.methods:
+                pointer_only = m.receiver.startswith('*') and not pointer
+                candidates.setdefault(m.name, []).append(PromotedMethod(m, path, pointer_only))
+            for f in inner.type.fields:
+                field_names[f.effective_name] = field_names.get(f.effective_name, 0) + 1
+                if f.embedded:
+                    next_level.append((f, path + [f.effective_name], pointer or f.type.startswith('*')))
+        for method_name, found in candidates.items():
+            if method_name not in blocked and len(found) == 1 and method_name not in field_names:
+                result.append(found[0])
+        blocked |= set(candidates) | set(field_names)
+        visited |= expanded
+        level = next_level
+    return sorted(result, key=lambda p: p.method.name)
+
+
+# outline 中各分组的标题与顺序
+_OUTLINE_SECTIONS = [
+    ('consts', {Kind.CONST}),
+    ('vars', {Kind.VAR}),
+    ('types', TYPE_KINDS),
+    ('funcs', {Kind.FUNC}),
+]
+
+
+def outline(symbols: Iterable[SymbolInfo]) -> str:
+    """按种类分组的名称与行号目录，方法嵌套在接收者类型下
+    
+    不含文档与函数体，适合作为低成本的摘要放在完整上下文之前。各组内保持输入顺序
+    （即源码顺序）；接收者类型不在 symbols 中的方法列在末尾的 methods 组。
+    """
+    symbols = list(symbols)
+    groups, orphans = group_by_type(symbols)
+    lines = []
+    for title, kinds in _OUTLINE_SECTIONS:
+        members = [s for s in symbols if s.type in kinds and not s.receiver]
+        if not members:
+            continue
+        if title != 'types':
+            lines.append(f"{title}: " + ", ".join(f"{s.name} (L{s.line})" for s in members))
+            continue
+        lines.append("types:")
+        for sym in members:
+            lines.append(f"  {sym.name} (L{sym.line})")
+            group = groups.get(sym.name)
+            if group is not None and group.type is sym:
+                lines.extend(f"    {m.name} (L{m.line})" for m in group.methods)
+    if orphans:
+        lines.append("methods: " + ", ".join(f"{s.key} (L{s.line})" for s in orphans))
+    return "\n".join(lines)
+
+
+class SortKey(str, Enum):
+    """sort_symbols 的排序方式"""
+    POSITION = 'position'  # 源码位置：(文件, 行, 列)
+    NAME = 'name'  # 名称，不区分大小写，相同时区分大小写
+    KIND = 'kind'  # 种类（按 Kind 的声明顺序，非 Go 种类排在其后并按字母序），再按名称
+    SIZE = 'size'  # 声明所占行数（不含文档注释），从大到小
+
+
+_KIND_ORDER = {kind: i for i, kind in enumerate(Kind)}
+
+
+def _name_key(sym: SymbolInfo) -> Tuple[str, str]:
+    return sym.name.casefold(), sym.name
+
+
+def _sort_key(by: SortKey) -> Callable[[SymbolInfo], Any]:
+    if by == SortKey.POSITION:
+        return lambda s: (s.file, s.line, s.column)
+    if by == SortKey.NAME:
+        return _name_key
+    if by == SortKey.KIND:
+        return lambda s: (_KIND_ORDER.get(s.type, len(_KIND_ORDER)), str(s.type), _name_key(s))
+    if by == SortKey.SIZE:
+        return lambda s: -(max(s.end_line, s.line) - s.line + 1)
+    raise ValueError(f"unknown sort key: {by}")
+
+
+def sort_symbols(symbols: List[SymbolInfo], by: SortKey = SortKey.POSITION):
+    """原地排序；排序是稳定的，键相同的符号保持原有顺序。by 未知时抛出 ValueError"""
+    symbols.sort(key=_sort_key(by))
+
+
+def sorted_symbols(symbols: Iterable[SymbolInfo], by: SortKey = SortKey.POSITION) -> List[SymbolInfo]:
+    """sort_symbols 的副本版本：返回新列表，不修改输入"""
+    result = list(symbols)
+    sort_symbols(result, by)
+    return result
+
+
+@dataclass
+class ImportSpec:
+    """Go 导入项"""
+    path: str
+    alias: str = ""  # 本地名；点导入为 "."，空白导入为 "_"
+    line: int = 0
+    group: int = 0  # 分组序号：每个 import 声明、以及括号内以空行分隔的每一段各为一组
+    
+    @property
+    def name(self) -> str:
+        """源码中引用该包所用的名称：别名，或路径最后一段（去掉 /vN、.vN 版本后缀与 go- 前缀）"""
+        if self.alias:
+            return self.alias
+        parts = self.path.split('/')
+        last = parts[-1]
+        if len(parts) > 1 and re.fullmatch(r'v[0-9]+', last):
+            last = parts[-2]
+        last = re.sub(r'\.v[0-9]+$', '', last)
+        return last[3:] if last.startswith('go-') else last
+    
+    @property
+    def is_dot(self) -> bool:
+        return self.alias == '.'
+    
+    @property
+    def is_blank(self) -> bool:
+        return self.alias == '_'
+    
+    def to_dict(self) -> dict:
+        return {
+            'path': self.path,
+            'alias': self.alias,
+            'dot': self.is_dot,
+            'blank': self.is_blank,
+            'line': self.line,
+            'group': self.group,
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'ImportSpec':
+        return cls(
+            path=data['path'],
+            alias=data.get('alias', ""),
+            line=data.get('line', 0),
+            group=data.get('group', 0),
+        )
+
+
+@dataclass
+class Dependencies:
+    """单个符号的依赖（见 dependencies）"""
+    internal: List[str] = field(default_factory=list)  # 引用的其他符号的 key
+    external: List[str] = field(default_factory=list)  # 引用的导入包路径
+
+
+def dependencies(symbols: Iterable[SymbolInfo], imports: Iterable[ImportSpec] = (),
+                 package_names: Iterable[str] = ()) -> Dict[str, Dependencies]:
+    """按 SymbolInfo.references 解析每个符号的依赖，返回 key -> Dependencies 的邻接表
+    
+    引用名与 symbols 中的非方法符号同名时记为包内依赖，与导入包名相同时记为外部依赖，
+    其余（内置标识符、局部变量等）忽略。基于标识符匹配，不做作用域分析：
+    局部变量与包级符号同名时会被误判为依赖。点导入与空白导入的包不参与匹配。
+    package_names 为同一包中其他文件定义的符号名，用于解析跨文件引用。
+    """
+    symbols = list(symbols)
+    names = {s.name for s in symbols if not s.receiver} | set(package_names)
+    packages: Dict[str, str] = {}
+    for spec in imports:
+        if not spec.is_dot and not spec.is_blank:
+            packages.setdefault(spec.name, spec.path)
+    
+    graph: Dict[str, Dependencies] = {}
+    for sym in symbols:
+        deps = graph.setdefault(sym.key, Dependencies())
+        for ref in sym.references:
+            if ref in names and ref != sym.name and ref not in deps.internal:
+                deps.internal.append(ref)
+            elif ref in packages and packages[ref] not in deps.external:
+                deps.external.append(packages[ref])
+    return graph
+
+
+def expand_deps(symbols: Iterable[SymbolInfo], seeds: Iterable[str], max_depth: int) -> List[SymbolInfo]:
+    """种子符号及其在 max_depth 步以内可达的包内依赖（见 dependencies），去重后按距离排列
+    
+    seeds 为符号的 key（方法为 类型.方法名）；同距离的符号按依赖的出现顺序。
+    max_depth 为 0 时只返回种子，为 1 时加上直接依赖。外部包与标准库的引用没有源码，不展开。
+    seeds 中有未知的 key 或 max_depth 为负数时抛出 ValueError。
+    """
+    if max_depth < 0:
+        raise ValueError(f"max_depth must be non-negative, got {max_depth}")
+    symbols = list(symbols)
+    by_key: Dict[str, SymbolInfo] = {}
+    for sym in symbols:
+        by_key.setdefault(sym.key, sym)
+    graph = dependencies(symbols)
+    
+    frontier = list(dict.fromkeys(seeds))
+    for key in frontier:
+        if key not in by_key:
+            raise ValueError(f"unknown symbol: {key}")
+    seen = set(frontier)
+    order = list(frontier)
+    for _ in range(max_depth):
+        following = []
+        for key in frontier:
+            for dep in graph[key].internal:
+                if dep in by_key and dep not in seen:
+                    seen.add(dep)
+                    following.append(dep)
+        if not following:
+            break
+        order.extend(following)
+        frontier = following
+    return [by_key[key] for key in order]
+
+
+def unreferenced(symbols: Iterable[SymbolInfo]) -> List[SymbolInfo]:
+    """包内没有被其他符号引用的未导出符号（可能是死代码），按输入顺序
+    
+    symbols 应为同一个包的全部符号（多个文件时先合并），否则跨文件的引用会被漏掉。
+    导出符号可能被包外使用，不参与判断；main、init、测试函数与空白标识符 _ 也排除在外。
+    
+    非方法符号按 dependencies 的包内依赖计算入边。方法不经过依赖图：其他符号中出现
+    x.name 形式的调用，或有接口声明了同名方法（未导出的方法只能满足包内的接口），
+    或保留了源码时其他声明的原文中出现 .name，都视为被引用。仍是启发式：只按名称匹配，
+    不区分接收者类型，没有源码时以方法值形式（f := x.name）的使用会被误判为未引用。
+    """
+    symbols = list(symbols)
+    referenced = set()
+    for deps in dependencies(symbols).values():
+        referenced.update(deps.internal)
+    interface_methods = {m.name for s in symbols for m in s.methods}
+    selectors = {c.rsplit('.', 1)[1] for s in symbols for c in s.calls if '.' in c}
+    
+    def used_method(sym: SymbolInfo) -> bool:
+        if sym.name in interface_methods or sym.name in selectors:
+            return True
+        pattern = re.compile(r'\.' + re.escape(sym.name) + r'\b')
+        return any(other is not sym and pattern.search(other.source(doc=False)) for other in symbols)
+    
+    result = []
+    for sym in symbols:
+        if is_exported(sym.name) or sym.name == '_' or sym.test_kind:
+            continue
+        if sym.type == Kind.FUNC and sym.name in ('main', 'init'):
+            continue
+        if sym.receiver:
+            if not used_method(sym):
+                result.append(sym)
+        elif sym.name not in referenced:
+            result.append(sym)
+    return result
+
+
+# 诊断级别
+ERROR = 'error'
+WARNING = 'warning'
+
+
+@dataclass
+class Position:
+    """源码位置"""
+    path: str
+    line: int
+    column: int = 0
+    
+    def __str__(self) -> str:
+        return f"{self.path}:{self.line}:{self.column}" if self.column else f"{self.path}:{self.line}"
+    
+    def to_dict(self) -> dict:
+        return {'path': self.path, 'line': self.line, 'column': self.column}
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'Position':
+        return cls(path=data['path'], line=data['line'], column=data.get('column', 0))
+
+
+@dataclass
+class Diagnostic:
+    """一条诊断：positions 按出现顺序列出所有相关位置"""
+    severity: str  # ERROR / WARNING
+    code: str  # 机器可读的类别，如 'syntax'、'redeclared'
+    message: str
+    positions: List[Position] = field(default_factory=list)
+    
+    def __str__(self) -> str:
+        if not self.positions:
+            return f"{self.severity}: {self.message}"
+        return f"{self.positions[0]}: {self.severity}: {self.message}"
+    
+    def to_dict(self) -> dict:
+        return {
+            'severity': self.severity,
+            'code': self.code,
+            'message': self.message,
+            'positions': [p.to_dict() for p in self.positions],
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'Diagnostic':
+        return cls(
+            severity=data['severity'],
+            code=data['code'],
+            message=data['message'],
+            positions=[Position.from_dict(p) for p in data.get('positions', [])],
+        )
+
+
+class IndexRow(NamedTuple):
+    """全文索引（如 SQLite FTS）的一行，字段顺序即列顺序，可直接用于 executemany
+    
+    signature 只有函数与方法才有，doc 在没有文档注释时为空字符串。
+    """
+    id: str  # 由 (file, qualified_name, kind) 派生，内容不变时跨次运行稳定，可用于 upsert
+    file: str
+    package: str
+    kind: str
+    name: str
+    qualified_name: str  # 包名.键，如 main.MyStruct.Method；没有 package 子句时为键
+    signature: str
+    doc: str
+    start_line: int
+    end_line: int
+
+
+def symbol_id(file: str, qualified_name: str, kind: str) -> str:
+    """IndexRow.id：三者的 SHA-256 前 32 位十六进制"""
+    return hashlib.sha256(f"{file}\0{qualified_name}\0{kind}".encode('utf-8')).hexdigest()[:32]
+
+
+def _unique_symbols(symbols: Iterable[SymbolInfo], default_file: str) -> List[SymbolInfo]:
+    seen = set()
+    result = []
+    for sym in symbols:
+        identity = (sym.file or default_file, sym.key, sym.type, sym.line)
+        if identity not in seen:
+            seen.add(identity)
+            result.append(sym)
+    return result
 
 
 @dataclass
@@ -22,6 +722,310 @@ class FileAnalysis:
     symbols: List[SymbolInfo] = field(default_factory=list)
     imports: List[str] = field(default_factory=list)
     exports: List[str] = field(default_factory=list)
+    import_specs: List[ImportSpec] = field(default_factory=list)  # 按源码顺序，含别名与分组
+    package: str = ""  # Go 的 package 子句名称
+    package_line: int = 0  # package 关键字所在行（0 表示没有 package 子句）
+    package_column: int = 0
+    is_test: bool = False  # 测试文件：文件名以 _test.go 结尾或包名以 _test 结尾
+    # 解析时遇到语法错误：symbols 只包含能够恢复的声明，errors 列出错误
+    partial: bool = False
+    errors: List[Diagnostic] = field(default_factory=list)
+    # 完整源码，供 SymbolInfo.source() 切片，用 attach_source() 设置。会让结果常驻整个文件内容的内存，
+    # 不需要原文时用 drop_source() 释放。不是 dataclass 字段：不参与比较，也不会被 asdict / to_dict 序列化
+    source = ""
+    # 解析时使用的扫描器（Go 为 go_scanner.GoScanner，其 position() 与 tokens 相当于 token.FileSet 与
+    # 完整 token 流），仅在分析器开启 retain_tokens 时保留，不得修改。同样不是字段，不参与比较与序列化
+    scanner = None
+    # lookup 的索引，首次查询时构建；不是字段，不随 replace 复制（副本会重新构建），也不参与序列化
+    _by_key = None
+    
+    def _derive(self, **changes) -> 'FileAnalysis':
+        """dataclasses.replace，并带上不属于字段的源码与扫描器"""
+        copy = replace(self, **changes)
+        copy.source, copy.scanner = self.source, self.scanner
+        return copy
+    
+    def filter_exported(self, fields: bool = True) -> 'FileAnalysis':
+        """返回只包含导出符号的副本（Go 规则：名称以大写字母开头）
+        
+        方法按自身名称判断，与接收者类型是否导出无关。
+        fields 为 True 时同时去掉结构体中未导出的字段。
+        """
+        symbols = []
+        for sym in self.symbols:
+            if not is_exported(sym.name):
+                continue
+            if fields and sym.fields:
+                sym = _replace_symbol(sym, fields=[f for f in sym.fields if is_exported(f.effective_name)])
+            symbols.append(sym)
+        return self._derive(symbols=symbols)
+    
+    def dedup(self) -> 'FileAnalysis':
+        """返回去掉重复符号的副本：(文件, 键, 种类, 起始行) 相同的符号只保留第一个"""
+        return self._derive(symbols=_unique_symbols(self.symbols, self.path))
+    
+    def merge(self, *others: 'FileAnalysis') -> 'FileAnalysis':
+        """合并多个分析结果（如多个文件或同一文件的多次解析），返回新结果
+        
+        符号按出现顺序拼接并按 (文件, 键, 种类, 起始行) 去重，先出现的保留；每个符号的
+        file 字段记录其来源文件。导入与导出取并集，诊断依次拼接，任一结果不完整时
+        合并结果也标记为 partial。path、language 等文件级字段沿用 self。
+        """
+        analyses = (self,) + others
+        symbols = []
+        for analysis in analyses:
+            symbols.extend(_replace_symbol(s, file=s.file or analysis.path) for s in analysis.symbols)
+        specs: Dict[Tuple[str, str], ImportSpec] = {}
+        for analysis in analyses:
+            for spec in analysis.import_specs:
+                specs.setdefault((spec.path, spec.alias), spec)
+        return self._derive(
+            symbols=_unique_symbols(symbols, self.path),
+            imports=list(dict.fromkeys(i for a in analyses for i in a.imports)),
+            exports=list(dict.fromkeys(e for a in analyses for e in a.exports)),
+            import_specs=list(specs.values()),
+            partial=any(a.partial for a in analyses),
+            errors=[e for a in analyses for e in a.errors],
+        )
+    
+    def filter_production(self) -> 'FileAnalysis':
+        """返回去掉测试符号的副本；测试文件的结果不含任何符号"""
+        return self._derive(symbols=[s for s in self.symbols if not s.is_test])
+    
+    def attach_source(self, source: str):
+        """重新关联源码（如从 JSON 还原后），source 必须与解析时的内容一致"""
+        self.source = source
+        for sym in self.symbols:
+            sym._source = source
+    
+    def drop_source(self):
+        """释放保留的源码；之后符号的 source() 返回空字符串"""
+        self.attach_source("")
+    
+    def lookup(self, name: str) -> Optional[SymbolInfo]:
+        """按名称查找符号；方法使用限定名 Type.Method（也接受 (*Type).Method）
+        
+        不存在时返回 None；同名符号重复声明时返回第一个。索引只在首次调用时构建一次，
+        之后的并发读取是安全的（并发的首次调用最多重复构建，结果相同）。
+        构建后再修改 symbols 不会反映到索引中。
+        """
+        index = self._by_key
+        if index is None:
+            index = {}
+            for sym in self.symbols:
+                index.setdefault(sym.key, sym)
+            self._by_key = index
+        if name.startswith('(*'):
+            name = name[2:].replace(')', '', 1)
+        return index.get(name)
+    
+    def slice_lines(self, start_line: int, end_line: int, expand: bool = False) -> str:
+        """返回第 start_line 到 end_line 行（含两端，从 1 开始）的原文，不含最后一行的换行符
+        
+        expand 为 True 时把范围扩大到与之相交的声明（含文档注释）的完整范围，避免截断半个函数。
+        行号越界、start_line > end_line 或没有保留源码时抛出 ValueError。
+        """
+        if not self.source:
+            raise ValueError(f"{self.path}: source is not available")
+        # 开头的 BOM 不算第一行的内容；换行符（含 \r\n）原样保留
+        starts = [1 if self.source.startswith('\ufeff') else 0] + [m.end() for m in re.finditer('\n', self.source)]
+        if starts[-1] == len(self.source):
+            starts.pop()  # 末尾换行之后没有新的一行
+        total = len(starts)
+        if not 1 <= start_line <= end_line <= total:
+            raise ValueError(f"{self.path}: line range {start_line}-{end_line} out of bounds (file has {total} lines)")
+        if expand:
+            changed = True
+            while changed:
+                changed = False
+                for sym in self.symbols:
+                    first = self.source.count('\n', 0, sym.source_offset) + 1 if sym.source_end else sym.line
+                    if first <= end_line and sym.end_line >= start_line and (first < start_line or sym.end_line > end_line):
+                        start_line, end_line = min(start_line, first), max(end_line, sym.end_line)
+                        changed = True
+        text = self.source[starts[start_line - 1]:starts[end_line] if end_line < total else len(self.source)]
+        if text.endswith('\n'):
+            text = text[:-2] if text.endswith('\r\n') else text[:-1]
+        return text
+    
+    def outline(self) -> str:
+        """文件的符号目录，见模块级 outline"""
+        return outline(self.symbols)
+    
+    def filter_by_kind(self, *kinds: Kind) -> List[SymbolInfo]:
+        """返回种类属于 kinds 的符号，保持源码顺序"""
+        wanted = set(kinds)
+        return [s for s in self.symbols if s.type in wanted]
+    
+    def token_count(self, model: str = 'cl100k') -> int:
+        """所有符号的 token 数之和"""
+        return sum(s.token_count(model) for s in self.symbols)
+    
+    def group_by_type(self) -> Tuple[Dict[str, TypeGroup], List[SymbolInfo]]:
+        """按接收者类型分组方法，见模块级 group_by_type"""
+        return group_by_type(self.symbols)
+    
+    def stats(self) -> 'Stats':
+        """本文件的统计（见 Stats）"""
+        stats = Stats()
+        stats.add(self)
+        return stats
+    
+    def dependencies(self) -> Dict[str, Dependencies]:
+        """文件内符号的依赖邻接表，见模块级 dependencies"""
+        return dependencies(self.symbols, self.import_specs)
+    
+    def expand_deps(self, seeds: Iterable[str], max_depth: int) -> List[SymbolInfo]:
+        """在本文件内展开依赖，见模块级 expand_deps"""
+        return expand_deps(self.symbols, seeds, max_depth)
+    
+    def unreferenced(self) -> List[SymbolInfo]:
+        """文件内未被引用的未导出符号，见模块级 unreferenced（同一包的其他文件不计入）"""
+        return unreferenced(self.symbols)
+    
+    def index_rows(self) -> Iterator[IndexRow]:
+        """按源码顺序逐个产生符号的索引行（生成器，不一次性构建列表）
+        
+        file 取符号的来源文件（合并结果中各自不同），重复声明的同名符号得到相同的 id。
+        """
+        prefix = f"{self.package}." if self.package else ""
+        for sym in self.symbols:
+            file = sym.file or self.path
+            qualified = prefix + sym.key
+            kind = str(sym.type)
+            yield IndexRow(symbol_id(file, qualified, kind), file, self.package, kind, sym.name, qualified,
+                           sym.signature, sym.docstring, sym.line, sym.end_line)
+    
+    def to_dict(self) -> dict:
+        """转换为稳定的 JSON 结构"""
+        symbols = []
+        for sym in self.symbols:
+            data = sym.to_dict()
+            data['file'] = sym.file or self.path
+            symbols.append(data)
+        return {
+            'schema_version': SCHEMA_VERSION,
+            'file': self.path,
+            'language': self.language,
+            'lines': self.lines,
+            'imports': list(self.imports),
+            'exports': list(self.exports),
+            'symbols': symbols,
+            'import_specs': [i.to_dict() for i in self.import_specs],
+            'package': self.package,
+            'package_line': self.package_line,
+            'package_col': self.package_column,
+            'is_test': self.is_test,
+            'partial': self.partial,
+            'errors': [e.to_dict() for e in self.errors],
+        }
+    
+    @classmethod
+    def from_dict(cls, data: dict) -> 'FileAnalysis':
+        """从 to_dict 的结果还原；遇到更高版本的格式时抛出 ValueError"""
+        version = data.get('schema_version')
+        if not isinstance(version, int) or version > SCHEMA_VERSION:
+            raise ValueError(f"unsupported schema_version: {version!r}")
+        return cls(
+            path=data['file'],
+            language=data['language'],
+            lines=data.get('lines', 0),
+            symbols=[SymbolInfo.from_dict(s) for s in data.get('symbols', [])],
+            imports=list(data.get('imports', [])),
+            exports=list(data.get('exports', [])),
+            import_specs=[ImportSpec.from_dict(i) for i in data.get('import_specs', [])],
+            package=data.get('package', ""),
+            package_line=data.get('package_line', 0),
+            package_column=data.get('package_col', 0),
+            is_test=data.get('is_test', False),
+            partial=data.get('partial', False),
+            errors=[Diagnostic.from_dict(e) for e in data.get('errors', [])],
+        )
+    
+    def to_json(self, fp: Optional[TextIO] = None, indent: Optional[int] = 2) -> str:
+        """序列化为 JSON（snake_case 键名，顶层携带 schema_version）
+        
+        顶层: schema_version, file, language, lines, imports, exports, symbols, import_specs, package, package_line, package_col, is_test, partial, errors
+        符号: name, kind, receiver, start_line, end_line, start_col, end_col, parameters, decorators, doc, fields, methods, embeds, is_alias,
+              type_params, signature, references, calls, is_test, test_kind, value, value_expr, value_type, source_offset, source_end,
+              fingerprint, complexity, is_deprecated, deprecation_note, file
+        字段: name, type, tag, embedded, line, doc, comment
+        接口方法: name, parameters, results, line, doc
+        类型参数: name, constraint
+        导入项: path, alias, dot, blank, line, group
+        诊断: severity, code, message, positions（path, line, column）
+        
+        若提供 fp，同时写入该文件对象。
+        """
+        text = json.dumps(self.to_dict(), ensure_ascii=False, indent=indent)
+        if fp is not None:
+            fp.write(text)
+        return text
+    
+    @classmethod
+    def from_json(cls, text: str) -> 'FileAnalysis':
+        """从 to_json 的输出还原"""
+        return cls.from_dict(json.loads(text))
+
+
+@dataclass
+class Stats:
+    """聚合统计：按种类计数、总行数，以及每个函数/方法的圈复杂度"""
+    files: int = 0
+    lines: int = 0
+    counts: Dict[str, int] = field(default_factory=dict)  # 种类 -> 符号数，按首次出现排序
+    complexity: Dict[str, int] = field(default_factory=dict)  # 函数/方法的 key（见 SymbolInfo.key）-> 圈复杂度
+    
+    def add(self, analysis: FileAnalysis):
+        """计入一个文件；同一包内 key 相同的函数（如不同构建约束下的实现）取最大复杂度"""
+        self.files += 1
+        self.lines += analysis.lines
+        for sym in analysis.symbols:
+            kind = str(sym.type)
+            self.counts[kind] = self.counts.get(kind, 0) + 1
+            if sym.complexity:
+                self.complexity[sym.key] = max(self.complexity.get(sym.key, 0), sym.complexity)
+    
+    def to_dict(self) -> dict:
+        return {
+            'files': self.files,
+            'lines': self.lines,
+            'counts': dict(self.counts),
+            'complexity': dict(self.complexity),
+        }
+
+
+def package_stats(files: Iterable[FileAnalysis]) -> Dict[str, Stats]:
+    """每个包的统计（按 group_by_package 分组，键为包名）"""
+    result: Dict[str, Stats] = {}
+    for name, group in group_by_package(files).items():
+        stats = result[name] = Stats()
+        for analysis in group:
+            stats.add(analysis)
+    return result
+
+
+@dataclass
+class ParseError:
+    """单个文件的解析错误（不中断整体分析）"""
+    path: str
+    message: str
+
+
+def group_by_package(files: Iterable[FileAnalysis]) -> Dict[str, List[FileAnalysis]]:
+    """按 package 子句的名称分组，组内与组间都保持输入顺序
+    
+    只看名称：不同目录下的同名包（如多个 main）会落入同一组，需要区分时先按目录
+    （index.package_of）划分。外部测试包 foo_test 自成一组。
+    """
+    groups: Dict[str, List[FileAnalysis]] = {}
+    for analysis in files:
+        groups.setdefault(analysis.package, []).append(analysis)
+    return groups
+
+
+class StopWalk(Exception):
+    """在流式分析的回调中抛出，提前结束遍历（不视为错误）"""
 
 
 @dataclass
@@ -0,0 +1,35 @@
+"""
+跨文件的静态诊断（Go）
+
+目前只有重复声明检查：同一包（同一目录且 package 子句相同）中，包级标识符被声明多次
+会被 Go 编译器拒绝。方法以 类型.方法名 判断；init 函数与空白标识符 _ 允许重复，不报告。
+
+互斥构建约束的文件（如 x_linux.go 与 x_windows.go）常常合法地声明同名符号，
+分析目录时应传入 build_context，只诊断同一构建配置下的文件。
+"""
+
+import posixpath
+from typing import Dict, List, Mapping, Tuple
+
+from .core import ERROR, Diagnostic, FileAnalysis, Position
+
+
+def diagnostics(files: Mapping[str, FileAnalysis]) -> List[Diagnostic]:
+    """对 analyze_dir 的结果做诊断，按首个位置排序"""
+    declared: Dict[Tuple[str, str, str], List[Position]] = {}
+    for path in sorted(files):
+        analysis = files[path]
+        package = (posixpath.dirname(path), analysis.package)
+        for sym in analysis.symbols:
+            if sym.name == '_' or (sym.name == 'init' and not sym.receiver):
+                continue
+            declared.setdefault((*package, sym.key), []).append(Position(path, sym.line, sym.column))
+
+    result = []
+    for (_, _, key), positions in declared.items():
+        if len(positions) > 1:
+            others = ', '.join(str(p) for p in positions[1:])
+            result.append(Diagnostic(ERROR, 'redeclared', f"{key} redeclared in this package (also at {others})",
+                                     positions))
+    result.sort(key=lambda d: (d.positions[0].path, d.positions[0].line, d.positions[0].column))
+    return result
@@ -0,0 +1,111 @@
+"""
+两个版本之间的符号变化（面向代码评审）
+
+符号按 (键, 种类) 对应：方法的键为 类型.方法名，同一键重复声明时取第一个；种类改变
+（如 type 改为 struct）记为删除加新增。对应上的符号在签名或声明原文的哈希不同时记为修改。
+原文不含文档注释，只改注释不算修改；任一侧没有保留源码（如从 JSON 还原）时只比较签名与成员。
+"""
+
+import hashlib
+import re
+from dataclasses import dataclass, field
+from typing import Dict, List, Tuple
+
+from .core import FileAnalysis, SymbolInfo
+
+_SPACES = re.compile(r'\s+')
+_PUNCT_SPACE = re.compile(r' ?([^\w\s]) ?')
+
+
+def body_hash(sym: SymbolInfo, ignore_whitespace: bool = False) -> str:
+    """声明原文（不含文档注释）的 SHA-256；没有源码时为空字符串
+
+    ignore_whitespace 为 True 时先规范化空白（连续空白合并，标点两侧的空白去掉），
+    只重排格式的改动得到相同的哈希。规范化不区分字符串字面量内部的空白。
+    """
+    text = sym.source(doc=False)
+    if not text:
+        return ""
+    if ignore_whitespace:
+        text = _PUNCT_SPACE.sub(r'\1', _SPACES.sub(' ', text)).strip()
+    return hashlib.sha256(text.encode('utf-8')).hexdigest()
+
+
+@dataclass
+class SymbolChange:
+    """一个被修改的符号"""
+    old: SymbolInfo
+    new: SymbolInfo
+    aspects: List[str] = field(default_factory=list)  # 变化的方面：'signature'、'body'
+    added_members: List[str] = field(default_factory=list)  # 新增的结构体字段或接口方法
+    removed_members: List[str] = field(default_factory=list)
+
+
+@dataclass
+class SymbolDiff:
+    """diff 的结果：added、modified 按新版本的源码顺序，removed 按旧版本的源码顺序"""
+    added: List[SymbolInfo] = field(default_factory=list)
+    removed: List[SymbolInfo] = field(default_factory=list)
+    modified: List[SymbolChange] = field(default_factory=list)
+
+    def is_empty(self) -> bool:
+        return not (self.added or self.removed or self.modified)
+
+
+def diff(old: FileAnalysis, new: FileAnalysis, ignore_whitespace: bool = False) -> SymbolDiff:
+    """比较同一文件的两个分析结果"""
+    before = _by_identity(old.symbols)
+    after = _by_identity(new.symbols)
+    result = SymbolDiff()
+    for identity, sym in after.items():
+        if identity not in before:
+            result.added.append(sym)
+            continue
+        change = _compare(before[identity], sym, ignore_whitespace)
+        if change is not None:
+            result.modified.append(change)
+    result.removed = [sym for identity, sym in before.items() if identity not in after]
+    return result
+
+
+def _by_identity(symbols: List[SymbolInfo]) -> Dict[Tuple[str, str], SymbolInfo]:
+    result: Dict[Tuple[str, str], SymbolInfo] = {}
+    for sym in symbols:
+        result.setdefault((sym.key, str(sym.type)), sym)
+    return result
+
+
+def _members(sym: SymbolInfo) -> List[str]:
+    return [f.effective_name for f in sym.fields] + [m.name for m in sym.methods] + list(sym.embeds)
+
+
+def _shape(sym: SymbolInfo) -> tuple:
+    """不依赖源码的结构比较：成员的名称与类型（不含行号和注释）"""
+    return (
+        [(f.effective_name, f.type, f.tag) for f in sym.fields],
+        [(m.name, m.parameters, m.results) for m in sym.methods],
+        sym.embeds,
+        sym.value_expr,
+    )
+
+
+def _compare(old: SymbolInfo, new: SymbolInfo, ignore_whitespace: bool):
+    aspects = []
+    if old.signature != new.signature:
+        aspects.append('signature')
+    old_hash, new_hash = body_hash(old, ignore_whitespace), body_hash(new, ignore_whitespace)
+    before, after = _members(old), _members(new)
+    if old_hash and new_hash:
+        if old_hash != new_hash:
+            aspects.append('body')
+    elif _shape(old) != _shape(new):
+        aspects.append('body')
+    if not aspects:
+        return None
+    return SymbolChange(
+        old=old,
+        new=new,
+        aspects=aspects,
+        added_members=[m for m in after if m not in before],
+        removed_members=[m for m in before if m not in after],
+    )
@@ -324,6 +324,10 @@ class ProjectAnalyzer:
                 if analysis and (analysis.symbols or analysis.imports):
                     # 确保 path 字段是相对路径
                     analysis.path = str(file_path.relative_to(self.project_path))
+                    for sym in analysis.symbols:
+                        sym.file = analysis.path
+                    # 项目报告不需要原文，避免整个项目的源码常驻内存
+                    analysis.drop_source()
                     self.result.files.append(analysis)
                     analyzed_count += 1
     
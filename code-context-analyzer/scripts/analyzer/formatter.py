@@ -1,6 +1,8 @@
 from __future__ import annotations
 
-from typing import List
+import io
+from typing import List, Optional, TextIO
+from xml.sax.saxutils import escape as xml_escape
 from .core import ProjectAnalysis, FileAnalysis, SymbolInfo
 
 def to_markdown(analysis: ProjectAnalysis) -> str:
@@ -114,3 +116,133 @@ def _format_symbol(sym: SymbolInfo, output_lines: List[str]):
     
     # Construct line: "- [C] ClassName L10"
     output_lines.append(f"- [{prefix}] {sym.name}{params_str}{dec_str}{line_info}")
+
+
+class Renderer:
+    """
+    Output format for a single file's analysis.
+    Subclass and implement render() to plug in a custom format; see render().
+    """
+
+    def render(self, fp: TextIO, analysis: FileAnalysis) -> None:
+        raise NotImplementedError
+
+
+def signature_line(sym: SymbolInfo) -> str:
+    """One-line declaration: the parsed signature, or a best-effort header built from the kind."""
+    if sym.signature:
+        return sym.signature
+    if sym.type in ('struct', 'interface'):
+        return f"type {sym.name} {sym.type}"
+    if sym.type in ('type', 'alias', 'func_type'):
+        return f"type {sym.name}"
+    if sym.type == 'const' and sym.value_expr:
+        return f"const {sym.name} = {sym.value_expr}"
+    if sym.type == 'variable' and sym.value_expr:
+        return f"var {sym.name} = {sym.value_expr}"
+    if sym.type == 'variable':
+        return f"var {sym.name} {sym.value_type}".rstrip()
+    params = f"({', '.join(sym.parameters)})" if sym.parameters else ""
+    return f"{sym.type} {sym.name}{params}"
+
+
+class MarkdownRenderer(Renderer):
+    """Markdown for humans: a heading per symbol, doc comment as prose, declaration in a fenced block."""
+
+    def render(self, fp: TextIO, analysis: FileAnalysis) -> None:
+        lang = analysis.language.lower()
+        fp.write(f"## {analysis.path} ({analysis.language})\n")
+        for sym in analysis.symbols:
+            fp.write(f"\n### {sym.key} ({sym.type}) L{sym.line}\n\n")
+            if sym.docstring:
+                fp.write(f"{sym.docstring}\n\n")
+            # Prefer the verbatim source; fall back to the signature when it was not retained
+            code = sym.source(doc=False) or signature_line(sym)
+            fence = "````" if "```" in code else "```"
+            fp.write(f"{fence}{lang}\n{code}\n{fence}\n")
+
+
+class SignatureRenderer(Renderer):
+    """Compact signatures only: a path comment followed by one declaration per line."""
+
+    def render(self, fp: TextIO, analysis: FileAnalysis) -> None:
+        fp.write(f"// {analysis.path}\n")
+        for sym in analysis.symbols:
+            fp.write(f"{signature_line(sym)}\n")
+
+
+class JSONRenderer(Renderer):
+    """The stable JSON schema of FileAnalysis.to_json()."""
+
+    def __init__(self, indent: Optional[int] = 2):
+        self.indent = indent
+
+    def render(self, fp: TextIO, analysis: FileAnalysis) -> None:
+        analysis.to_json(fp, indent=self.indent)
+        fp.write("\n")
+
+
+class DelimitedRenderer(Renderer):
+    """
+    Each symbol wrapped in delimiters with a header naming the file, symbol and line range,
+    for assembling LLM prompts. style selects the delimiters:
+
+    - "xml": <file path="x.go" symbol="Function" lines="24-26">...</file>
+    - "markdown": a "### x.go: Function (lines 24-26)" heading followed by a fenced block
+    - any other string is a str.format template with the fields path, symbol, kind, start,
+      end, doc and body, e.g. "=== {path}#{symbol} ===\n{body}\n"
+
+    The symbol is named by its key (Type.Method for methods). With doc_in_header the doc
+    comment moves from the body into the header (an XML doc attribute, a paragraph under the
+    Markdown heading, the {doc} field of a template); otherwise it stays in the body and {doc}
+    is empty. Symbols whose source was not retained fall back to the signature.
+    """
+
+    def __init__(self, style: str = "xml", doc_in_header: bool = False):
+        self.style = style
+        self.doc_in_header = doc_in_header
+
+    def render(self, fp: TextIO, analysis: FileAnalysis) -> None:
+        for sym in analysis.symbols:
+            fp.write(self.wrap(analysis, sym))
+
+    def wrap(self, analysis: FileAnalysis, sym: SymbolInfo) -> str:
+        """The delimited text of a single symbol, ending with a newline."""
+        path = sym.file or analysis.path
+        doc = sym.docstring if self.doc_in_header else ""
+        body = sym.source(doc=not self.doc_in_header)
+        if not body:
+            body = signature_line(sym)
+            if sym.docstring and not self.doc_in_header:
+                body = "\n".join("// " + line if line else "//" for line in sym.docstring.split("\n")) + "\n" + body
+        span = f"{sym.line}-{sym.end_line or sym.line}"
+        if self.style == "xml":
+            doc_attr = f' doc={_xml_attr(doc)}' if doc else ""
+            return (f'<file path={_xml_attr(path)} symbol={_xml_attr(sym.key)} lines="{span}"{doc_attr}>\n'
+                    f'{body}\n</file>\n')
+        if self.style == "markdown":
+            fence = "````" if "```" in body else "```"
+            prose = f"{doc}\n\n" if doc else ""
+            return (f"### {path}: {sym.key} (lines {span})\n\n{prose}"
+                    f"{fence}{analysis.language.lower()}\n{body}\n{fence}\n")
+        text = self.style.format(path=path, symbol=sym.key, kind=sym.type, start=sym.line,
+                                 end=sym.end_line or sym.line, doc=doc, body=body)
+        return text if text.endswith("\n") else text + "\n"
+
+
+def _xml_attr(value: str) -> str:
+    return '"' + xml_escape(value, {'"': "&quot;", "\n": "&#10;"}) + '"'
+
+
+def render(analysis: FileAnalysis, renderer: Optional[Renderer] = None, fp: Optional[TextIO] = None) -> str:
+    """
+    Render a file analysis with the given renderer (Markdown by default).
+    Returns the rendered text; when fp is given it is also written there.
+    """
+    renderer = renderer or MarkdownRenderer()
+    buf = io.StringIO()
+    renderer.render(buf, analysis)
+    text = buf.getvalue()
+    if fp is not None:
+        fp.write(text)
+    return text
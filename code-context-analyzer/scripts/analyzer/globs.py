@@ -0,0 +1,76 @@
+"""
+路径 glob 匹配（相对路径，以 / 分隔）
+
+语义与 path.Match 一致，另外支持 doublestar 的 **：
+- *     匹配一段路径中的任意字符（不含 /）
+- ?     匹配一个字符（不含 /）
+- [...] 字符类，[!...] 或 [^...] 取反；紧跟在开头的 ] 是普通字符（如 []a]）
+- **    作为完整的一段时匹配零段或多段目录，如 **/*.pb.go 同时匹配 a.pb.go 与 x/y/a.pb.go
+
+模式必须匹配整个路径。
+"""
+
+import re
+from functools import lru_cache
+from typing import Iterable, Pattern
+
+
+@lru_cache(maxsize=256)
+def compile_glob(pattern: str) -> Pattern:
+    """把 glob 模式编译为正则；字符类未闭合或无效（如 [z-a]）时抛出 ValueError"""
+    out = []
+    segments = pattern.split('/')
+    for idx, segment in enumerate(segments):
+        last = idx == len(segments) - 1
+        if segment == '**':
+            # 末尾的 ** 匹配任意剩余路径，中间的 **/ 匹配零段或多段目录
+            out.append('.*' if last else '(?:[^/]*/)*')
+            continue
+        out.append(_segment(segment, pattern))
+        if not last:
+            out.append('/')
+    try:
+        return re.compile(''.join(out) + r'\Z')
+    except re.error as e:
+        raise ValueError(f"invalid glob pattern: {pattern!r}: {e}") from None
+
+
+def _segment(segment: str, pattern: str) -> str:
+    out = []
+    i = 0
+    while i < len(segment):
+        ch = segment[i]
+        if ch == '*':
+            out.append('[^/]*')
+        elif ch == '?':
+            out.append('[^/]')
+        elif ch == '[':
+            # 与 path.Match 一致：紧跟在 [、[!、[^ 之后的 ] 是普通字符，\ 转义下一个字符
+            j = i + 2 if segment[i + 1:i + 2] in ('!', '^') else i + 1
+            body = ['^'] if j > i + 1 else []
+            first = j
+            while j < len(segment) and (segment[j] != ']' or j == first):
+                if segment[j] == '\\' and j + 1 < len(segment):
+                    j += 1
+                body.append(segment[j] if segment[j] == '-' else re.escape(segment[j]))
+                j += 1
+            if j >= len(segment):
+                raise ValueError(f"invalid glob pattern: {pattern!r}")
+            out.append('[' + ''.join(body) + ']')
+            i = j
+        elif ch == '\\' and i + 1 < len(segment):
+            i += 1
+            out.append(re.escape(segment[i]))
+        else:
+            out.append(re.escape(ch))
+        i += 1
+    return ''.join(out)
+
+
+def match(pattern: str, path: str) -> bool:
+    """path（POSIX 相对路径）是否完整匹配 pattern"""
+    return compile_glob(pattern).match(path) is not None
+
+
+def match_any(patterns: Iterable[str], path: str) -> bool:
+    return any(match(p, path) for p in patterns)
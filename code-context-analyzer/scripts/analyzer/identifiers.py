@@ -0,0 +1,36 @@
+"""
+标识符切分（排序与全文索引共用，保证两者对同一文本切出相同的词）
+
+- camelCase / PascalCase 在大小写边界处切开：GlobalVar -> global, var
+- 连续大写视为一个缩写词，到下一个首字母大写的单词前结束：HTTPServer -> http, server
+- 数字自成一段：Base64Encode -> base, 64, encode
+- 下划线与其他非单词字符都是分隔符：max_retry -> max, retry
+- 非 ASCII 的单词不切分
+
+输出全部小写；由多段组成的单词额外保留整体（如 globalvar），使完整标识符的查询也能命中。
+"""
+
+import re
+from typing import List
+
+_WORD = re.compile(r'[^\W_]+')
+_CAMEL = re.compile(r'[A-Z]+(?=[A-Z][a-z])|[A-Z]?[a-z]+|[A-Z]+|[0-9]+')
+
+
+def split_identifier(ident: str, keep_whole: bool = True) -> List[str]:
+    """切分单个标识符；keep_whole 为 True 且切出多段时在末尾追加整体"""
+    tokens: List[str] = []
+    for word in _WORD.findall(ident):
+        parts = _CAMEL.findall(word) if word.isascii() else []
+        if len(parts) > 1:
+            tokens.extend(p.lower() for p in parts)
+            if keep_whole:
+                tokens.append(word.lower())
+        else:
+            tokens.append(word.lower())
+    return tokens
+
+
+def tokenize(text: str) -> List[str]:
+    """切分任意文本（名称、文档注释、签名）为小写词，规则同 split_identifier"""
+    return split_identifier(text)
@@ -0,0 +1,123 @@
+"""
+Go 符号的增量索引
+
+面向编辑器集成：一次只有一个文件变化时，用 Index.update / Index.remove 只重新解析该文件，
+其他文件的分析结果保持不变。
+
+派生数据按包（同一目录）维护：
+- 方法分组（group_by_type）在包内任一文件变化后惰性重算；
+- 依赖边按文件缓存。变化文件的边总是重算；同包其他文件只有在引用了新增或删除的
+  包级名称时才重算，其余保持原样。
+"""
+
+import posixpath
+from pathlib import Path
+from typing import Dict, Iterable, List, Optional, Set, Tuple, Union
+
+from .core import Dependencies, FileAnalysis, ParseError, SymbolInfo, TypeGroup, dependencies, group_by_type, outline
+from .parsers.go import GoAnalyzer
+from .parsers.go_build import BuildContext
+
+
+def package_of(path: str) -> str:
+    """文件所属的包：相对路径的目录部分（根目录为空字符串）"""
+    return posixpath.dirname(path)
+
+
+class Index:
+    """按文件维护的 Go 符号索引，键为相对根目录的 POSIX 路径"""
+
+    def __init__(self, analyzer: Optional[GoAnalyzer] = None, build_context: Optional[BuildContext] = None):
+        self.analyzer = analyzer or GoAnalyzer()
+        # 未指定时沿用分析器的构建上下文，使 update 与 build 的文件过滤一致
+        self.build_context = build_context if build_context is not None else self.analyzer.build_context
+        self.files: Dict[str, FileAnalysis] = {}
+        self._packages: Dict[str, Set[str]] = {}  # 包 -> 文件
+        self._deps: Dict[str, Dict[str, Dependencies]] = {}  # 文件 -> 依赖邻接表
+        self._groups: Dict[str, Tuple[Dict[str, TypeGroup], List[SymbolInfo]]] = {}  # 包 -> 分组缓存
+
+    @classmethod
+    def build(cls, root: Union[str, Path], analyzer: Optional[GoAnalyzer] = None,
+              build_context: Optional[BuildContext] = None) -> Tuple['Index', List[ParseError]]:
+        """全量分析 root 并建立索引，返回 (索引, 解析错误)"""
+        index = cls(analyzer, build_context)
+        results, errors = index.analyzer.analyze_dir(root, build_context=index.build_context)
+        for path, analysis in results.items():
+            index.files[path] = analysis
+            index._packages.setdefault(package_of(path), set()).add(path)
+        for path in index.files:
+            index._deps[path] = index._file_dependencies(path)
+        return index, errors
+
+    def update(self, path: str, src: Union[bytes, str]):
+        """用新内容重新解析单个文件；构建约束不满足时等同于 remove"""
+        if self.build_context is not None:
+            text = src.decode('utf-8', errors='ignore') if isinstance(src, bytes) else src
+            if not self.build_context.match(posixpath.basename(path), text):
+                self.remove(path)
+                return
+        pkg = package_of(path)
+        before = self._package_names(pkg)
+        self.files[path] = self.analyzer.analyze_source(path, src)
+        self._packages.setdefault(pkg, set()).add(path)
+        self._invalidate(pkg, path, before)
+
+    def remove(self, path: str):
+        """从索引中删除文件；文件不存在时不做任何事"""
+        if path not in self.files:
+            return
+        pkg = package_of(path)
+        before = self._package_names(pkg)
+        del self.files[path]
+        self._deps.pop(path, None)
+        self._packages[pkg].discard(path)
+        if not self._packages[pkg]:
+            del self._packages[pkg]
+        self._invalidate(pkg, None, before)
+
+    def symbols(self) -> Iterable[SymbolInfo]:
+        """按路径顺序遍历所有符号"""
+        for path in sorted(self.files):
+            yield from self.files[path].symbols
+
+    def group_by_type(self, package: str) -> Tuple[Dict[str, TypeGroup], List[SymbolInfo]]:
+        """包内跨文件的方法分组，见 core.group_by_type"""
+        if package not in self._groups:
+            files = sorted(self._packages.get(package, ()))
+            self._groups[package] = group_by_type(s for f in files for s in self.files[f].symbols)
+        return self._groups[package]
+
+    def outline(self, package: str) -> str:
+        """包的符号目录（按文件路径、再按源码顺序），见 core.outline"""
+        return outline(s for f in sorted(self._packages.get(package, ())) for s in self.files[f].symbols)
+
+    def slice(self, path: str, start_line: int, end_line: int, expand: bool = False) -> str:
+        """已索引文件的行范围原文，见 FileAnalysis.slice_lines；文件不在索引中时抛出 ValueError"""
+        if path not in self.files:
+            raise ValueError(f"{path}: not in index")
+        return self.files[path].slice_lines(start_line, end_line, expand)
+
+    def dependencies(self, path: str) -> Dict[str, Dependencies]:
+        """文件内符号的依赖邻接表，包内依赖可以指向同包其他文件的符号"""
+        return self._deps.get(path, {})
+
+    def _package_names(self, package: str) -> Set[str]:
+        return {s.name for f in self._packages.get(package, ()) for s in self.files[f].symbols if not s.receiver}
+
+    def _file_dependencies(self, path: str) -> Dict[str, Dependencies]:
+        analysis = self.files[path]
+        return dependencies(analysis.symbols, analysis.import_specs, self._package_names(package_of(path)))
+
+    def _invalidate(self, package: str, changed: Optional[str], before: Set[str]):
+        """重算受影响的派生数据：changed 为被更新的文件（删除时为 None）"""
+        self._groups.pop(package, None)
+        if changed is not None:
+            self._deps[changed] = self._file_dependencies(changed)
+        delta = before ^ self._package_names(package)
+        if not delta:
+            return
+        for path in self._packages.get(package, ()):
+            if path == changed:
+                continue
+            if any(ref in delta for s in self.files[path].symbols for ref in s.references):
+                self._deps[path] = self._file_dependencies(path)
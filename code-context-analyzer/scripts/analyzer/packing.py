@@ -0,0 +1,72 @@
+"""
+在 token 预算内组装上下文（贪心）
+
+按得分从高到低依次加入符号，累计 token 数（SymbolInfo.token_count），
+加入下一个符号会超出预算时停止。给出依赖邻接表时，每个符号的直接包内依赖
+先于它本身加入，并与它一起计入成本：符号连同尚未加入的依赖放不下时同样停止。
+
+得分为 0 的符号与查询无关，不会单独入选，但仍可作为依赖被带入。
+符号之间的分隔符不计入 token 数。
+"""
+
+from dataclasses import dataclass, field
+from typing import Dict, Iterable, List, Optional
+
+from .core import Dependencies, SymbolInfo
+from .ranking import ScoredSymbol
+
+
+@dataclass
+class PackedContext:
+    """pack 的结果：symbols 按加入顺序排列（依赖在前）"""
+    symbols: List[ScoredSymbol] = field(default_factory=list)
+    tokens: int = 0
+
+    def text(self, separator: str = '\n\n') -> str:
+        """拼接所有符号的 context_text()"""
+        return separator.join(s.symbol.context_text() for s in self.symbols)
+
+
+def pack(scored: Iterable[ScoredSymbol], budget: int, model: str = 'cl100k',
+         graph: Optional[Dict[str, Dependencies]] = None) -> PackedContext:
+    """按得分顺序贪心选取不超过 budget 个 token 的符号
+
+    scored 不必有序：先按得分稳定排序，得分相同时保持输入顺序（rank 的输出已按位置排列）。
+    graph 为 key -> Dependencies 的邻接表（见 core.dependencies），依赖按名称在 scored
+    中查找，找不到的忽略。budget 为负数或 model 未知时抛出 ValueError。
+    """
+    if budget < 0:
+        raise ValueError(f"budget must be non-negative, got {budget}")
+    scored = sorted(scored, key=lambda s: -s.score)
+    by_name: Dict[str, ScoredSymbol] = {}
+    for item in scored:
+        if not item.symbol.receiver:
+            by_name.setdefault(item.symbol.name, item)
+
+    result = PackedContext()
+    included = set()
+    for item in scored:
+        if item.score <= 0:
+            continue
+        if id(item.symbol) in included:
+            continue
+        bundle: List[ScoredSymbol] = []
+        for ref in _direct_deps(item.symbol, graph):
+            dep = by_name.get(ref)
+            if dep is not None and dep is not item and id(dep.symbol) not in included:
+                bundle.append(dep)
+        bundle.append(item)
+        cost = sum(d.symbol.token_count(model) for d in bundle)
+        if result.tokens + cost > budget:
+            break
+        for d in bundle:
+            included.add(id(d.symbol))
+            result.symbols.append(d)
+        result.tokens += cost
+    return result
+
+
+def _direct_deps(symbol: SymbolInfo, graph: Optional[Dict[str, Dependencies]]) -> List[str]:
+    if not graph or symbol.key not in graph:
+        return []
+    return graph[symbol.key].internal
@@ -1,61 +1,1290 @@
+import hashlib
+import os
 import re
+from concurrent.futures import ProcessPoolExecutor
 from pathlib import Path
-from ..core import FileAnalysis, SymbolInfo
+from typing import Callable, Dict, Iterable, Iterator, List, Optional, Set, Tuple, Union
+from ..cache import ParseCache
+from ..core import ERROR, SCHEMA_VERSION, Diagnostic, FieldInfo, FileAnalysis, ImportSpec, Kind, MethodSig, ParseError, Position, StopWalk, SymbolInfo, TypeParam
+from ..globs import compile_glob, match_any
+from .go_build import BuildContext
+from .go_const import Value, evaluate, format_value
+from .go_scanner import BOM, GoScanner, Token
+
+
+def _is_semicolon(tok: Token) -> bool:
+    """显式或自动插入的分号"""
+    return tok.kind == 'op' and tok.value in (';', '\n')
+
+
+_OPERAND_END_KINDS = {'ident', 'int', 'float', 'imag', 'char', 'string'}
+_BINARY_OPS = {
+    '+', '-', '*', '/', '%', '&', '|', '^', '<<', '>>', '&^', '&&', '||', '<-',
+    '==', '!=', '<', '<=', '>', '>=', '=', ':=',
+    '+=', '-=', '*=', '/=', '%=', '&=', '|=', '^=', '<<=', '>>=', '&^=',
+}
+
+
+_CLOSERS = {'(': ')', '[': ']', '{': '}'}
+
+
+def _describe(tok: Optional[Token]) -> str:
+    """错误信息中对 token 的描述，与 go/parser 的写法一致"""
+    if tok is None:
+        return "EOF"
+    if tok.value == '\n':
+        return "newline"
+    return f"'{tok.value}'"
+
+
+def _terminated(literal: str) -> bool:
+    """解释型字符串或 rune 字面量是否以未转义的引号结束"""
+    if len(literal) < 2 or literal[-1] != literal[0]:
+        return False
+    backslashes = len(literal[1:-1]) - len(literal[1:-1].rstrip('\\'))
+    return backslashes % 2 == 0
+
+
+def _ends_operand(tok: Token) -> bool:
+    return tok.kind in _OPERAND_END_KINDS or tok.value in (')', ']', '}')
+
+
+def _is_binary(toks: List[Token], idx: int, expr: bool) -> bool:
+    """判断 toks[idx] 是否为二元运算符；类型表达式中只有约束里的 | 是二元的"""
+    tok = toks[idx]
+    if tok.kind != 'op' or tok.value not in _BINARY_OPS or idx == 0:
+        return False
+    if not expr:
+        return tok.value in ('|', '=')
+    return _ends_operand(toks[idx - 1])
+
+
+_TYPE_START_KEYWORDS = {'func', 'map', 'chan', 'struct', 'interface'}
+
+
+def _array_brackets(items: List[Token], expr: bool) -> Dict[int, int]:
+    """切片/数组类型的 [ 的位置 -> 对应 ] 的位置
+
+    [] 或 [N] 其后紧跟类型时才是切片/数组类型；泛型实例化 List[T] 与类型参数列表
+    [T any] 的 ] 之后是分隔符，或括号内有相邻的两个操作数（或顶层逗号），不计入。
+    表达式中 a[i] * b、a[i][j] 有二义，只认标识符与类型关键字开头的元素类型。
+    """
+    pairs: List[Tuple[int, int]] = []
+    stack: List[int] = []
+    for idx, tok in enumerate(items):
+        if tok.value in ('(', '[', '{'):
+            stack.append(idx)
+        elif tok.value in (')', ']', '}') and stack:
+            start = stack.pop()
+            if tok.value == ']' and items[start].value == '[' and idx + 1 < len(items):
+                pairs.append((start, idx))
+
+    result: Dict[int, int] = {}
+    # 从右向左判断，[2][3]int 中前一个括号取决于后一个
+    for start, idx in sorted(pairs, reverse=True):
+        nxt = items[idx + 1]
+        if not (nxt.kind == 'ident' or (nxt.kind == 'keyword' and nxt.value in _TYPE_START_KEYWORDS)
+                or (nxt.value == '[' and idx + 1 in result)
+                or (not expr and nxt.value in ('*', '[', '<-', '('))):
+            continue
+        content = items[start + 1:idx]
+        depth = 0
+        list_like = False
+        for k, t in enumerate(content):
+            if t.value in ('(', '[', '{'):
+                depth += 1
+            elif t.value in (')', ']', '}'):
+                depth -= 1
+            elif depth == 0 and t.value == ',':
+                list_like = True
+            elif (k > 0 and depth == 0 and (t.kind in _OPERAND_END_KINDS or t.kind == 'keyword')
+                  and content[k - 1].kind in _OPERAND_END_KINDS):
+                list_like = True
+        if not list_like:
+            result[start] = idx
+    return result
+
+
+def render(toks: List[Token], expr: bool = False) -> str:
+    """把 token 序列渲染为接近 gofmt 风格的单行文本
+
+    expr 为 False 时按类型/参数列表处理（* 是指针运算符）；
+    为 True 时按表达式处理，二元运算符两侧加空格。
+    """
+    # 去掉块首尾多余的分号（多行 struct/interface 渲染为单行时产生）
+    items = [t for i, t in enumerate(toks)
+             if not (_is_semicolon(t) and (i == 0 or toks[i - 1].value == '{'
+                                           or i + 1 == len(toks) or toks[i + 1].value == '}'))]
+    # 多行参数列表/字面量的尾随逗号在单行中去掉
+    items = [t for i, t in enumerate(items)
+             if not (t.value == ',' and i + 1 < len(items) and items[i + 1].value in (')', ']', '}'))]
+    arrays = _array_brackets(items, expr)
+    # 数组长度是表达式：类型上下文中方括号内的二元运算符同样加空格
+    in_length = [False] * len(items)
+    for start, end in arrays.items():
+        for k in range(start + 1, end):
+            in_length[k] = True
+    out: List[str] = []
+    braces: List[bool] = []  # True 表示 struct/interface 类型体
+    for idx, tok in enumerate(items):
+        v = ';' if _is_semicolon(tok) else tok.value
+        local = expr or in_length[idx]
+        if idx > 0:
+            prev = items[idx - 1]
+            pv = ';' if _is_semicolon(prev) else prev.value
+            if pv in (';', ','):
+                space = True
+            elif v in (')', ']', ',', ';', '.', ':'):
+                space = False
+            elif v == '}':
+                space = bool(braces) and braces[-1] and pv != '{'
+            elif pv == '{':
+                space = bool(braces) and braces[-1]
+            elif pv in ('(', '[', '.', '~', '!'):
+                space = False
+            elif pv == ':':
+                space = bool(braces)
+            elif v == '...':
+                space = not expr and prev.kind == 'ident'
+            elif pv == '...':
+                space = False
+            elif pv == 'chan' and v == '<-':
+                space = False
+            elif _is_binary(items, idx, local) or _is_binary(items, idx - 1, expr or in_length[idx - 1]):
+                space = True
+            elif prev.kind == 'op' and pv in _BINARY_OPS:
+                # 一元运算符（*T、&x、<-ch），其后不加空格；chan<- T 除外
+                space = pv == '<-' and idx > 1 and items[idx - 2].value == 'chan'
+            elif v in ('(', '['):
+                if prev.kind == 'keyword':
+                    space = pv not in ('func', 'map')
+                elif idx in arrays:
+                    # 名称与切片/数组类型之间：p []byte、b [16]int
+                    space = prev.kind == 'ident' or pv == ')'
+                else:
+                    space = pv == ')' and not expr
+            elif v == '{':
+                space = pv == ')'
+            elif pv == ']':
+                space = False
+            else:
+                space = True
+            if space:
+                out.append(' ')
+        out.append(v)
+        if v == '{':
+            braces.append(idx > 0 and items[idx - 1].value in ('struct', 'interface'))
+        elif v == '}' and braces:
+            braces.pop()
+    return ''.join(out)
+
+
+# 解析器输出格式变化（即使 SCHEMA_VERSION 不变）时递增，使旧的缓存条目失效
+PARSER_VERSION = 16
+
+
+# 预声明类型：T(x) 是类型转换而不是调用
+_PREDECLARED_TYPES = {
+    'bool', 'byte', 'complex64', 'complex128', 'error', 'float32', 'float64', 'int', 'int8',
+    'int16', 'int32', 'int64', 'rune', 'string', 'uint', 'uint8', 'uint16', 'uint32', 'uint64',
+    'uintptr', 'any',
+}
+
+# go test 识别的测试函数前缀
+_TEST_FUNC_PREFIXES = {'Test': 'test', 'Benchmark': 'benchmark', 'Example': 'example', 'Fuzz': 'fuzz'}
+
+
+# 无类型常量的默认类型，按 Go 规范中 "后出现者优先" 的顺序
+_UNTYPED_DEFAULTS = [('int', 'int'), ('char', 'rune'), ('float', 'float64'), ('imag', 'complex128')]
+_COMPARISON_OPS = {'==', '!=', '<', '<=', '>', '>=', '&&', '||', '!'}
+
+
+def _literal_type(expr: List[Token]) -> str:
+    """仅凭初始化表达式能确定的类型：字面量与其运算、复合字面量、函数字面量、预声明类型的转换；否则为空"""
+    if not expr:
+        return ""
+    first, last = expr[0], expr[-1]
+    if first.value == '&' and len(expr) > 1:
+        inner = _literal_type(expr[1:])
+        return '*' + inner if inner and last.value == '}' else ""
+    if first.value == 'func':
+        body = next((j for j, t in enumerate(expr) if t.value == '{'), len(expr))
+        return render(expr[:body])
+    if last.value == '}':
+        # 复合字面量 T{...}：取与末尾 } 匹配的 { 之前的类型
+        depth = 0
+        for j in range(len(expr) - 1, -1, -1):
+            if expr[j].value == '}':
+                depth += 1
+            elif expr[j].value == '{':
+                depth -= 1
+                if depth == 0:
+                    return render(expr[:j]) if j > 0 else ""
+        return ""
+    if first.kind == 'ident' and first.value in _PREDECLARED_TYPES and len(expr) > 2 \
+            and expr[1].value == '(' and last.value == ')':
+        return first.value
+    kinds = set()
+    for tok in expr:
+        if tok.kind == 'ident' and tok.value in ('true', 'false'):
+            kinds.add('bool')
+        elif tok.kind == 'op' and tok.value in _COMPARISON_OPS:
+            kinds.add('bool')
+        elif tok.kind == 'ident' and tok.value == 'iota':
+            kinds.add('int')
+        elif tok.kind in ('ident', 'keyword'):
+            return ""
+        elif tok.kind != 'op':
+            kinds.add(tok.kind)
+        elif tok.value not in ('+', '-', '*', '/', '%', '&', '|', '^', '<<', '>>', '&^', '(', ')'):
+            return ""
+    if 'bool' in kinds:
+        return 'bool'
+    if 'string' in kinds:
+        return 'string'
+    return next((name for kind, name in reversed(_UNTYPED_DEFAULTS) if kind in kinds), "")
+
+
+def test_func_kind(name: str) -> str:
+    """按 go test 的规则判断测试函数的子类：前缀后为空或不以小写字母开头，如 TestXxx、Example"""
+    for prefix, kind in _TEST_FUNC_PREFIXES.items():
+        if name.startswith(prefix):
+            rest = name[len(prefix):]
+            if not rest or not rest[0].islower():
+                return kind
+    return ""
+
+
+def _fingerprint(decl: List[Token], name_index: int, local: Set[str]) -> str:
+    """函数的结构指纹：去掉注释与空白、略过函数名（decl[name_index]），局部名称按首次出现的顺序
+    换成占位符后取哈希
+
+    分号统一为 ';'，位于 ) 与 } 之前的分号去掉，使单行与多行写法一致。选择器右侧
+    （x.Name 的 Name）不是局部名称的引用，保持原样。
+    """
+    renamed: Dict[str, str] = {}
+    parts: List[str] = []
+    for j, tok in enumerate(decl):
+        if j == name_index:
+            continue
+        if _is_semicolon(tok):
+            parts.append(';')
+            continue
+        if tok.value in (')', '}') and parts and parts[-1] == ';':
+            parts.pop()
+        if tok.kind == 'ident' and tok.value in local and decl[j - 1].value != '.':
+            parts.append(renamed.setdefault(tok.value, f"${len(renamed)}"))
+        else:
+            parts.append(tok.value)
+    while parts and parts[-1] == ';':
+        parts.pop()
+    return hashlib.sha256(' '.join(parts).encode('utf-8')).hexdigest()[:32]
+
+
+# 编译器指令注释（// 之后没有空格），与 go/ast CommentGroup.Text 一样不计入文档
+_DIRECTIVE = re.compile(r'//(?:line |extern |export |[a-z0-9]+:[a-z0-9])')
+
+_BRANCH_KEYWORDS = {'if', 'for', 'case'}
+_BRANCH_OPS = {'&&', '||'}
+
+
+def _branch_points(toks: Iterable[Token]) -> int:
+    return sum(1 for t in toks if (t.kind == 'keyword' and t.value in _BRANCH_KEYWORDS)
+               or (t.kind == 'op' and t.value in _BRANCH_OPS))
+
+
+def cyclomatic_complexity(code: str) -> int:
+    """一段 Go 代码（通常是函数体）的近似圈复杂度：1 + if、for、case、&&、|| 的个数
+
+    按 token 计数，字符串与注释中的关键字不计；default 与 else 不是分支点。
+    """
+    return 1 + _branch_points(GoScanner(code).code_tokens())
+
+
+def deprecation_note(doc: str) -> Optional[str]:
+    """文档中以 "Deprecated:" 开头的段落的说明文字（到下一个空行为止）；没有这样的段落时返回 None
+
+    与 Go 惯例一致，"Deprecated:" 必须位于段首。
+    """
+    lines = doc.split('\n')
+    for i, line in enumerate(lines):
+        if line.startswith('Deprecated:') and (i == 0 or not lines[i - 1].strip()):
+            paragraph = [line[len('Deprecated:'):].strip()]
+            for rest in lines[i + 1:]:
+                if not rest.strip():
+                    break
+                paragraph.append(rest.strip())
+            return ' '.join(p for p in paragraph if p)
+    return None
+
+
+def _patterns(patterns: Optional[Iterable[str]]) -> Tuple[str, ...]:
+    """规范化 include / exclude 选项并提前校验模式"""
+    if patterns is None:
+        return ()
+    if isinstance(patterns, str):
+        patterns = [patterns]
+    result = tuple(patterns)
+    for pattern in result:
+        compile_glob(pattern)
+    return result
+
+
+def _analyze_file_task(args) -> Tuple[str, Optional[FileAnalysis], Optional[str]]:
+    """工作进程入口（需在模块顶层以便序列化）：返回 (相对路径, 结果, 错误信息)"""
+    analyzer, rel, path, build_context, cache = args
+    try:
+        return rel, analyzer._analyze_file(rel, path, build_context, cache), None
+    except Exception as e:
+        return rel, None, str(e)
+
 
 class GoAnalyzer:
-    """Go 语言分析器（基于正则）"""
-    
-    IMPORT_PATTERN = r'import\s+(?:\(\s*([\s\S]*?)\s*\)|"([^"]+)")'
-    # 宽松的正则：允许函数名后跟泛型 [T any] 等，不再强制匹配 (
-    FUNC_PATTERN = r'^func\s+(?:\([^)]+\)\s+)?(\w+)'
-    # 宽松的正则：允许结构体名后跟泛型
-    STRUCT_PATTERN = r'^type\s+(\w+).*\s+struct\s*\{'
-    # 宽松的正则：允许接口名后跟泛型
-    INTERFACE_PATTERN = r'^type\s+(\w+).*\s+interface\s*\{'
-    # 类型别名：type Alias int 或 type Alias = int (Go 1.9+)
-    TYPE_ALIAS_PATTERN = r'^type\s+(\w+)\s+=?\s*(\w+|\[|func|chan|map)'
-    # 常量定义
-    CONST_PATTERN = r'^(?:const|var)\s+(\w+)\s+'
-    
+    """Go 语言分析器（基于词法扫描）"""
+
+    DECL_KEYWORDS = {'import', 'const', 'var', 'type', 'func'}
+    # analyze_dir 默认跳过的目录（隐藏目录总是跳过）
+    DEFAULT_SKIP_DIRS = {'vendor', 'testdata'}
+
+    def __init__(self, expand_embedded: bool = False, exported_only: bool = False,
+                 include_tests: bool = True, build_context: Optional[BuildContext] = None,
+                 workers: Optional[int] = None, cache_dir: Optional[Union[str, Path]] = None,
+                 include: Optional[Iterable[str]] = None, exclude: Optional[Iterable[str]] = None,
+                 retain_tokens: bool = False):
+        """所有选项都有默认值，不传时行为与不带选项的分析器完全相同
+
+        include_tests 到 exclude 是 analyze_dir / analyze_dir_stream 的默认值，调用时显式传入的参数优先。
+        选项非法（workers < 1、cache_dir 是已存在的普通文件、glob 模式无效）时抛出 ValueError。
+        """
+        if workers is not None and workers < 1:
+            raise ValueError(f"workers must be >= 1, got {workers}")
+        if cache_dir is not None and Path(cache_dir).is_file():
+            raise ValueError(f"cache_dir is not a directory: {cache_dir}")
+        # 为 True 时把接口中嵌入的、同一文件内定义的接口展开为方法
+        self.expand_embedded = expand_embedded
+        # 为 True 时只保留导出符号与导出字段（见 FileAnalysis.filter_exported）
+        self.exported_only = exported_only
+        self.include_tests = include_tests
+        self.build_context = build_context
+        self.workers = workers
+        self.cache_dir = cache_dir
+        self.include = _patterns(include)
+        self.exclude = _patterns(exclude)
+        # 为 True 时每个符号保留其声明的 token 序列（SymbolInfo.node()），结果保留扫描器
+        # （FileAnalysis.scanner），供自定义分析使用；默认不保留以节省内存
+        self.retain_tokens = retain_tokens
+
     def analyze(self, file_path: Path) -> FileAnalysis:
         """分析 Go 文件"""
+        return self.analyze_source(str(file_path), file_path.read_bytes())
+
+    def analyze_dir(self, root: Union[str, Path],
+                    skip_dirs: Optional[Iterable[str]] = None,
+                    build_context: Optional[BuildContext] = None,
+                    workers: Optional[int] = None,
+                    cache_dir: Optional[Union[str, Path]] = None,
+                    include_tests: Optional[bool] = None,
+                    include: Optional[Iterable[str]] = None,
+                    exclude: Optional[Iterable[str]] = None
+                    ) -> Tuple[Dict[str, FileAnalysis], List[ParseError]]:
+        """递归分析目录下的所有 .go 文件
+
+        返回 (结果, 错误)：结果以相对 root 的 POSIX 路径为键、按遍历顺序排列；单个文件失败时
+        记录到错误列表并继续，错误列表按路径排序。skip_dirs 覆盖默认的跳过目录 DEFAULT_SKIP_DIRS。
+        提供 build_context 时跳过文件名或 //go:build、// +build 约束不满足的文件；
+        为 None 时不做过滤。
+        跟随符号链接，但同一真实目录只访问一次以避免链接成环。
+
+        workers 为并行解析的进程数，默认 os.cpu_count()；为 1 时在当前进程中串行解析。
+        目录遍历总在当前进程完成，结果与 workers 无关。
+
+        cache_dir 指定时启用磁盘缓存（见 cache.ParseCache）：内容未变的文件直接从缓存加载；
+        为 None 时不使用缓存。清空缓存用 ParseCache(cache_dir).clear()。
+
+        include_tests 为 False 时跳过测试文件（见 FileAnalysis.is_test），只返回生产代码。
+
+        include、exclude 为 glob 模式（见 globs 模块，支持 **），与相对 root 的 POSIX 路径整体匹配：
+        给出 include 时只分析至少匹配一个模式的文件，匹配任一 exclude 的文件总被跳过（exclude 优先）。
+        例如 exclude=['**/*.pb.go'] 跳过所有目录下生成的 protobuf 代码。模式无效时抛出 ValueError。
+
+        build_context、workers、cache_dir、include_tests、include、exclude 为 None 时取构造时的选项。
+        """
+        build_context, cache_dir, include_tests, include, exclude = self._dir_options(
+            build_context, cache_dir, include_tests, include, exclude)
+        workers = self.workers if workers is None else workers
+        workers = (os.cpu_count() or 1) if workers is None else workers
+        if workers < 1:
+            raise ValueError(f"workers must be >= 1, got {workers}")
+        errors: List[ParseError] = []
+        files = list(self._walk(Path(root), skip_dirs, include_tests, errors, include, exclude))
+
+        cache = ParseCache(cache_dir) if cache_dir is not None else None
+        tasks = [(self, rel, path, build_context, cache) for rel, path in files]
+        if workers > 1 and len(tasks) > 1:
+            with ProcessPoolExecutor(max_workers=min(workers, len(tasks))) as pool:
+                outcomes = list(pool.map(_analyze_file_task, tasks, chunksize=max(1, len(tasks) // (workers * 4))))
+        else:
+            outcomes = [_analyze_file_task(t) for t in tasks]
+
+        results: Dict[str, FileAnalysis] = {}
+        for rel, analysis, error in outcomes:
+            if error is not None:
+                errors.append(ParseError(rel, error))
+            elif analysis is not None and (include_tests or not analysis.is_test):
+                results[rel] = analysis
+        errors.sort(key=lambda e: e.path)
+        return results, errors
+
+    def analyze_dir_stream(self, root: Union[str, Path],
+                           on_symbol: Callable[[SymbolInfo], None],
+                           on_file: Optional[Callable[[FileAnalysis], None]] = None,
+                           skip_dirs: Optional[Iterable[str]] = None,
+                           build_context: Optional[BuildContext] = None,
+                           cache_dir: Optional[Union[str, Path]] = None,
+                           include_tests: Optional[bool] = None,
+                           include: Optional[Iterable[str]] = None,
+                           exclude: Optional[Iterable[str]] = None) -> List[ParseError]:
+        """逐个文件分析目录，并对每个符号调用 on_symbol，不保留全部结果
+
+        用于超大代码库：同一时刻只有一个文件的结果在内存中。每个文件先调用 on_file
+        （可取得 path、package、import_specs 等文件级信息），再按源码顺序对其符号调用 on_symbol。
+        回调中抛出 StopWalk 时立即停止并正常返回；其他异常原样抛出。
+        文件的遍历顺序、过滤与缓存选项与 analyze_dir 相同，解析在当前进程中串行进行。
+        返回已遇到的错误（按遇到的顺序）。
+        """
+        build_context, cache_dir, include_tests, include, exclude = self._dir_options(
+            build_context, cache_dir, include_tests, include, exclude)
+        errors: List[ParseError] = []
+        cache = ParseCache(cache_dir) if cache_dir is not None else None
         try:
-            content = file_path.read_text(encoding='utf-8', errors='ignore')
-            lines_list = content.splitlines()
-            lines = len(lines_list)
-        except UnicodeDecodeError:
-            return FileAnalysis(path=str(file_path), language='Go', lines=0)
-        
-        symbols = []
-        imports = []
-        
-        # 提取导入
-        for match in re.finditer(self.IMPORT_PATTERN, content):
-            if match.group(1):  # 多行导入
-                for line in match.group(1).splitlines():
-                    line = line.strip().strip('"')
-                    if line and not line.startswith('//'):
-                        imports.append(line.split()[-1].strip('"'))
-            elif match.group(2):  # 单行导入
-                imports.append(match.group(2))
-        
-        # 提取符号
-        for i, line in enumerate(lines_list, 1):
-            if match := re.search(self.FUNC_PATTERN, line):
-                symbols.append(SymbolInfo(name=match.group(1), type='function', line=i))
-            elif match := re.search(self.STRUCT_PATTERN, line):
-                symbols.append(SymbolInfo(name=match.group(1), type='struct', line=i))
-            elif match := re.search(self.INTERFACE_PATTERN, line):
-                symbols.append(SymbolInfo(name=match.group(1), type='interface', line=i))
-            elif match := re.search(self.TYPE_ALIAS_PATTERN, line):
-                symbols.append(SymbolInfo(name=match.group(1), type='type', line=i))
-            elif match := re.search(self.CONST_PATTERN, line):
-                symbols.append(SymbolInfo(name=match.group(1), type='const', line=i))
-        
-        return FileAnalysis(
-            path=str(file_path),
+            for rel, path in self._walk(Path(root), skip_dirs, include_tests, errors, include, exclude):
+                try:
+                    analysis = self._analyze_file(rel, path, build_context, cache)
+                except Exception as e:
+                    errors.append(ParseError(rel, str(e)))
+                    continue
+                if analysis is None or (not include_tests and analysis.is_test):
+                    continue
+                if on_file is not None:
+                    on_file(analysis)
+                for sym in analysis.symbols:
+                    on_symbol(sym)
+        except StopWalk:
+            pass
+        return errors
+
+    def _dir_options(self, build_context: Optional[BuildContext], cache_dir: Optional[Union[str, Path]],
+                     include_tests: Optional[bool], include: Optional[Iterable[str]],
+                     exclude: Optional[Iterable[str]]):
+        """调用参数为 None 时回退到构造时的选项"""
+        return (
+            self.build_context if build_context is None else build_context,
+            self.cache_dir if cache_dir is None else cache_dir,
+            self.include_tests if include_tests is None else include_tests,
+            self.include if include is None else _patterns(include),
+            self.exclude if exclude is None else _patterns(exclude),
+        )
+
+    def _walk(self, root: Path, skip_dirs: Optional[Iterable[str]], include_tests: bool,
+              errors: List[ParseError], include: Tuple[str, ...] = (),
+              exclude: Tuple[str, ...] = ()) -> Iterator[Tuple[str, Path]]:
+        """按路径顺序惰性产生 (相对路径, 路径)；无法访问的目录记录到 errors"""
+        skip = self.DEFAULT_SKIP_DIRS if skip_dirs is None else set(skip_dirs)
+        visited = set()
+        for dirpath, dirnames, filenames in os.walk(root, followlinks=True):
+            try:
+                st = os.stat(dirpath)
+            except OSError as e:
+                errors.append(ParseError(Path(dirpath).relative_to(root).as_posix(), str(e)))
+                dirnames[:] = []
+                continue
+            if (st.st_dev, st.st_ino) in visited:
+                dirnames[:] = []
+                continue
+            visited.add((st.st_dev, st.st_ino))
+
+            dirnames[:] = sorted(d for d in dirnames if d not in skip and not d.startswith('.'))
+            for name in sorted(filenames):
+                if name.endswith('.go') and (include_tests or not name.endswith('_test.go')):
+                    path = Path(dirpath) / name
+                    rel = path.relative_to(root).as_posix()
+                    if include and not match_any(include, rel):
+                        continue
+                    if match_any(exclude, rel):
+                        continue
+                    yield rel, path
+
+    def _analyze_file(self, rel: str, path: Path, build_context: Optional[BuildContext],
+                      cache: Optional[ParseCache] = None) -> Optional[FileAnalysis]:
+        """读取并分析单个文件；构建约束不满足时返回 None"""
+        data = path.read_bytes()
+        if build_context and not build_context.match(path.name, data.decode('utf-8', errors='ignore')):
+            return None
+        # 缓存不保存 token，保留 token 时总是重新解析
+        if cache is None or self.retain_tokens:
+            return self.analyze_source(rel, data)
+
+        # 测试文件的判定依赖文件名，内容相同的测试与非测试文件不能共用条目
+        key = ParseCache.key(data, f"{self._cache_version()}/test={rel.endswith('_test.go')}")
+        analysis = cache.get(key)
+        if analysis is not None:
+            # 缓存按内容共享，路径（含诊断位置中的路径）与源码在加载后重新关联
+            analysis.path = rel
+            analysis.attach_source(data.decode('utf-8', errors='ignore'))
+            for sym in analysis.symbols:
+                sym.file = rel
+            for diagnostic in analysis.errors:
+                for pos in diagnostic.positions:
+                    pos.path = rel
+            return analysis
+        analysis = self.analyze_source(rel, data)
+        cache.put(key, analysis)
+        return analysis
+
+    def _cache_version(self) -> str:
+        """缓存键中的版本部分：解析器与输出格式版本，以及影响输出的选项"""
+        return (f"go/{PARSER_VERSION}/schema{SCHEMA_VERSION}/expand_embedded={self.expand_embedded}"
+                f"/exported_only={self.exported_only}")
+
+    def analyze_source(self, filename: str, src: Union[bytes, str]) -> FileAnalysis:
+        """分析内存中的 Go 源码
+
+        filename 仅用于结果中的 path 字段，不会访问磁盘。
+        """
+        content = src.decode('utf-8', errors='ignore') if isinstance(src, bytes) else src
+        # 与扫描器一致只按 '\n' 分行：str.splitlines 还会在 \f、\u2028 等字符处分行，使行号错位
+        lines_list = [l[:-1] if l.endswith('\r') else l for l in content.split('\n')]
+        if lines_list[-1] == '':
+            lines_list.pop()
+        lines = len(lines_list)
+        if content.startswith(BOM):
+            lines_list[0] = lines_list[0][len(BOM):]
+
+        scanner = GoScanner(content)
+        toks = scanner.code_tokens()
+        symbols: List[SymbolInfo] = []
+        import_specs: List[ImportSpec] = []
+        consts: Dict[str, Value] = {}
+
+        errors = self._lexical_errors(scanner, filename)
+        package = ""
+        package_pos = (0, 0)
+        i = 0
+        if toks and toks[0].kind == 'keyword' and toks[0].value == 'package':
+            package_pos = (toks[0].line, toks[0].column)
+            if len(toks) > 1 and toks[1].kind == 'ident':
+                package = toks[1].value
+            i = self._decl_end(toks, 0)[0] + 1
+        else:
+            found = toks[0] if toks else None
+            errors.append(self._syntax_error(filename, scanner, found, f"expected 'package', found {_describe(found)}"))
+
+        # 只在顶层识别声明；括号未闭合的声明在下一个行首的声明关键字处恢复，其符号不输出
+        while i < len(toks):
+            tok = toks[i]
+            if _is_semicolon(tok):
+                i += 1
+                continue
+            if tok.kind != 'keyword' or tok.value not in self.DECL_KEYWORDS:
+                errors.append(self._syntax_error(filename, scanner, tok, f"expected declaration, found {_describe(tok)}"))
+                i = self._next_decl(toks, i + 1)
+                continue
+            end, bad, message = self._decl_end(toks, i)
+            if message:
+                errors.append(self._syntax_error(filename, scanner, bad, message))
+                i = self._next_decl(toks, end)
+                continue
+            decl = toks[i:end]
+            if tok.value == 'import':
+                group = import_specs[-1].group + 1 if import_specs else 0
+                import_specs.extend(self._parse_imports(decl, lines_list, group))
+                i = end + 1
+                continue
+            if tok.value == 'func':
+                parsed = self._parse_func(decl, scanner)
+            else:
+                parsed = self._parse_gen_decl(decl, scanner, consts)
+            if self.retain_tokens:
+                node = tuple(decl)
+                for sym in parsed:
+                    sym._node = node
+            symbols.extend(parsed)
+            i = end + 1
+        errors.sort(key=lambda e: (e.positions[0].line, e.positions[0].column))
+
+        for sym in symbols:
+            sym.docstring, doc_line = self._extract_doc(lines_list, sym.line)
+            # 原文范围从文档注释（若有）开始，到声明结束
+            if doc_line:
+                indent = len(lines_list[doc_line - 1]) - len(lines_list[doc_line - 1].lstrip())
+                sym.source_offset = scanner.line_starts[doc_line - 1] + indent
+            else:
+                sym.source_offset = scanner.line_starts[sym.line - 1] + sym.column - 1
+            sym.source_end = scanner.line_starts[sym.end_line - 1] + sym.end_column - 1
+            note = deprecation_note(sym.docstring)
+            if note is not None:
+                sym.is_deprecated, sym.deprecation_note = True, note
+            for member in sym.fields + sym.methods:
+                member.docstring, _ = self._extract_doc(lines_list, member.line)
+        if self.expand_embedded:
+            self._expand_embedded(symbols)
+
+        is_test = filename.endswith('_test.go') or package.endswith('_test')
+        for sym in symbols:
+            sym.file = filename
+            sym.is_test = is_test
+            if is_test and sym.type == Kind.FUNC:
+                sym.test_kind = test_func_kind(sym.name)
+
+        result = FileAnalysis(
+            path=filename,
             language='Go',
             lines=lines,
             symbols=symbols,
-            imports=list(set(imports)),
+            imports=list(dict.fromkeys(i.path for i in import_specs)),
+            import_specs=import_specs,
+            package=package,
+            package_line=package_pos[0],
+            package_column=package_pos[1],
+            is_test=is_test,
+            partial=bool(errors),
+            errors=errors,
+        )
+        result.attach_source(content)
+        if self.retain_tokens:
+            result.scanner = scanner
+        return result.filter_exported() if self.exported_only else result
+
+    def _parse_imports(self, decl: List[Token], lines_list: List[str], group: int) -> List[ImportSpec]:
+        """解析 import 声明：import [name] "path" 或 import ( ... )；括号内的空行开启新分组"""
+        if len(decl) > 1 and decl[1].value == '(':
+            specs = self._split_specs(decl[2:self._matching(decl, 1)])
+        else:
+            specs = [decl[1:]]
+        result: List[ImportSpec] = []
+        for spec in specs:
+            path = next((t for t in spec if t.kind == 'string'), None)
+            if path is None:
+                continue
+            if result and any(not l.strip() for l in lines_list[result[-1].line:path.line - 1]):
+                group += 1
+            # 路径前的 token 即本地名（标识符、"." 或 "_"）
+            alias = spec[0].value if spec[0] is not path else ""
+            result.append(ImportSpec(path=path.value[1:-1], alias=alias, line=path.line, group=group))
+        return result
+
+    @staticmethod
+    def _decl_end(toks: List[Token], start: int) -> Tuple[int, Optional[Token], str]:
+        """返回 (顶层声明结束处分号的下标, 出错的 token, 错误信息)
+
+        声明在括号深度回到 0 的第一个分号处结束。括号不匹配时返回出错位置；括号未闭合时
+        在下一个位于行首的声明关键字处停止（返回该关键字的下标），到达文件末尾时返回 len(toks)。
+        """
+        stack: List[str] = []
+        for j in range(start, len(toks)):
+            tok = toks[j]
+            if stack and tok.column == 1 and tok.kind == 'keyword' and tok.value in GoAnalyzer.DECL_KEYWORDS:
+                return j, tok, f"expected '{_CLOSERS[stack[-1]]}', found {_describe(tok)}"
+            if tok.kind != 'op':
+                continue
+            if tok.value in _CLOSERS:
+                stack.append(tok.value)
+            elif tok.value in (')', ']', '}'):
+                if not stack or _CLOSERS[stack[-1]] != tok.value:
+                    expected = f"'{_CLOSERS[stack[-1]]}'" if stack else "declaration"
+                    return j, tok, f"expected {expected}, found {_describe(tok)}"
+                stack.pop()
+            elif not stack and _is_semicolon(tok):
+                return j, None, ""
+        if stack:
+            return len(toks), None, f"expected '{_CLOSERS[stack[-1]]}', found EOF"
+        return len(toks), None, ""
+
+    def _next_decl(self, toks: List[Token], start: int) -> int:
+        """出错后的同步点：下一个位于行首的声明关键字"""
+        for j in range(start, len(toks)):
+            if toks[j].column == 1 and toks[j].kind == 'keyword' and toks[j].value in self.DECL_KEYWORDS:
+                return j
+        return len(toks)
+
+    @staticmethod
+    def _syntax_error(filename: str, scanner: GoScanner, tok: Optional[Token], message: str) -> Diagnostic:
+        """tok 为 None 时位置取文件末尾"""
+        line, column = (tok.line, tok.column) if tok is not None else scanner.position(len(scanner.src))
+        return Diagnostic(ERROR, 'syntax', message, [Position(filename, line, column)])
+
+    def _lexical_errors(self, scanner: GoScanner, filename: str) -> List[Diagnostic]:
+        """非法字符与未结束的字面量、块注释"""
+        errors: List[Diagnostic] = []
+        for tok in scanner.tokens:
+            if tok.kind == 'illegal':
+                message = f"illegal character U+{ord(tok.value):04X} '{tok.value}'"
+            elif tok.kind == 'comment' and tok.value.startswith('/*') and (len(tok.value) < 4 or not tok.value.endswith('*/')):
+                message = "comment not terminated"
+            elif tok.kind == 'string' and tok.value.startswith('`') and (len(tok.value) < 2 or not tok.value.endswith('`')):
+                message = "raw string literal not terminated"
+            elif tok.kind in ('string', 'char') and tok.value[0] in '"\'' and not _terminated(tok.value):
+                message = "string literal not terminated" if tok.kind == 'string' else "rune literal not terminated"
+            else:
+                continue
+            errors.append(self._syntax_error(filename, scanner, tok, message))
+        return errors
+
+    @staticmethod
+    def _make_symbol(name: str, kind: str, first: Token, last: Token, scanner: GoScanner, **extra) -> SymbolInfo:
+        """按首尾 token 生成带位置信息的符号；结束列指向最后一个字符之后（与 go/token 的 End() 一致）"""
+        end_line, end_column = scanner.position(last.end)
+        return SymbolInfo(
+            name=name,
+            type=kind,
+            line=first.line,
+            end_line=end_line,
+            column=first.column,
+            end_column=end_column,
+            **extra,
         )
+
+    def _parse_func(self, decl: List[Token], scanner: GoScanner) -> List[SymbolInfo]:
+        """解析 func 声明：func [(recv)] Name[TypeParams](params) results [body]"""
+        i = 1
+        receiver = ""
+        signature = "func "
+        if i < len(decl) and decl[i].value == '(':
+            close = self._matching(decl, i)
+            recv = decl[i + 1:close]
+            signature += f"({render(recv)}) "
+            # 具名接收者：首个 token 是标识符且其后不是 [ 或 .
+            if len(recv) >= 2 and recv[0].kind == 'ident' and recv[1].value not in ('[', '.'):
+                recv = recv[1:]
+            receiver = render(recv)
+            i = close + 1
+        if i >= len(decl) or decl[i].kind != 'ident':
+            return []
+        name = decl[i].value
+        name_index = i
+        signature += name
+        i += 1
+
+        type_params = []
+        if i < len(decl) and decl[i].value == '[':
+            close = self._matching(decl, i)
+            type_params = self._parse_type_params(decl[i + 1:close])
+            signature += render(decl[i:close + 1])
+            i = close + 1
+
+        parameters = []
+        calls = []
+        fingerprint = ""
+        complexity = 1
+        if i < len(decl) and decl[i].value == '(':
+            close = self._matching(decl, i)
+            parameters = self._parse_params(decl[i + 1:close])
+            body = self._body_start(decl, close + 1)
+            signature += render(decl[i:body])
+            calls = self._calls(decl[body:])
+            complexity += _branch_points(decl[body:])
+            # 局部名称：接收者、类型参数、参数与具名返回值，以及函数体内声明的名称
+            local = {t.name for t in type_params}
+            local.update(n for n, _ in self._param_pairs(decl[i + 1:close]) if n)
+            if close + 1 < len(decl) and decl[close + 1].value == '(':
+                local.update(n for n, _ in self._param_pairs(decl[close + 2:self._matching(decl, close + 1)]) if n)
+            if decl[1].value == '(' and len(decl) > 3 and decl[2].kind == 'ident' and decl[3].value not in ('[', '.', ')'):
+                local.add(decl[2].value)
+            local.update(self._body_locals(decl[body:]))
+            fingerprint = _fingerprint(decl, name_index, local)
+
+        return [self._make_symbol(name, Kind.METHOD if receiver else Kind.FUNC, decl[0], decl[-1], scanner,
+                                  receiver=receiver, type_params=type_params,
+                                  parameters=parameters, signature=signature,
+                                  references=self._references(decl, name), calls=calls,
+                                  fingerprint=fingerprint, complexity=complexity)]
+
+    @staticmethod
+    def _body_locals(body: List[Token]) -> Set[str]:
+        """函数体内声明的名称：:= 左侧的标识符，以及 var / const 之后的标识符列表（不区分作用域）"""
+        names: Set[str] = set()
+        for j, tok in enumerate(body):
+            if tok.kind == 'op' and tok.value == ':=':
+                k = j - 1
+                while k >= 0 and body[k].kind == 'ident':
+                    names.add(body[k].value)
+                    if k >= 1 and body[k - 1].value == ',':
+                        k -= 2
+                    else:
+                        break
+            elif tok.kind == 'keyword' and tok.value in ('var', 'const'):
+                k = j + 1
+                if k < len(body) and body[k].value == '(':
+                    k += 1
+                while k < len(body) and body[k].kind == 'ident':
+                    names.add(body[k].value)
+                    if k + 1 < len(body) and body[k + 1].value == ',':
+                        k += 2
+                    else:
+                        break
+        return names
+
+    @staticmethod
+    def _calls(body: List[Token]) -> List[str]:
+        """函数体中调用的函数，按首次出现去重，保留原始选择器写法（如 fmt.Println、s.items[i].Get）
+
+        被调用者须以标识符开头，由 .x、[...] 与 (...) 组成；预声明类型的转换（如 string(b)）不计入。
+        """
+        calls: List[str] = []
+        for j, tok in enumerate(body):
+            if tok.value != '(' or j == 0 or body[j - 1].kind != 'ident' and body[j - 1].value != ']':
+                continue
+            start = GoAnalyzer._operand_start(body, j - 1)
+            if start is None:
+                continue
+            if start == j - 1 and body[start].value in _PREDECLARED_TYPES:
+                continue
+            callee = render(body[start:j], expr=True)
+            if callee not in calls:
+                calls.append(callee)
+        return calls
+
+    @staticmethod
+    def _operand_start(toks: List[Token], end: int) -> Optional[int]:
+        """从 toks[end] 向前找到以标识符开头的主表达式的起点；不是这种形式时返回 None"""
+        k = end
+        while k >= 0:
+            tok = toks[k]
+            if tok.value in (')', ']'):
+                k = GoAnalyzer._matching_back(toks, k) - 1
+                continue
+            if tok.kind != 'ident':
+                return None
+            if k >= 2 and toks[k - 1].value == '.' and (toks[k - 2].kind == 'ident' or toks[k - 2].value in (')', ']')):
+                k -= 2
+                continue
+            return k
+        return None
+
+    @staticmethod
+    def _matching_back(toks: List[Token], close: int) -> int:
+        """与 toks[close] 处的 ) 或 ] 配对的左括号下标；找不到时返回 0"""
+        depth = 0
+        for j in range(close, -1, -1):
+            if toks[j].value in (')', ']', '}'):
+                depth += 1
+            elif toks[j].value in ('(', '[', '{'):
+                depth -= 1
+                if depth == 0:
+                    return j
+        return 0
+
+    @staticmethod
+    def _body_start(decl: List[Token], start: int) -> int:
+        """返回函数体左花括号的下标（跳过返回值中 struct{} / interface{} 的花括号）"""
+        j = start
+        while j < len(decl):
+            if decl[j].value == '{':
+                if j > 0 and decl[j - 1].value in ('struct', 'interface'):
+                    j = GoAnalyzer._matching(decl, j) + 1
+                    continue
+                return j
+            if decl[j].value in ('(', '['):
+                j = GoAnalyzer._matching(decl, j) + 1
+                continue
+            j += 1
+        return len(decl)
+
+    def _parse_gen_decl(self, decl: List[Token], scanner: GoScanner,
+                        consts: Optional[Dict[str, Value]] = None) -> List[SymbolInfo]:
+        """解析 const / var / type 声明，支持分组形式 keyword ( spec; spec; ... )
+
+        consts 收集本文件中已求值的常量，供后续常量表达式引用。
+        """
+        consts = {} if consts is None else consts
+        keyword = decl[0].value
+        if len(decl) > 1 and decl[1].value == '(':
+            specs = self._split_specs(decl[2:self._matching(decl, 1)])
+            grouped = True
+        else:
+            specs = [decl[1:]]
+            grouped = False
+
+        symbols = []
+        last_exprs: List[List[Token]] = []
+        last_type: List[Token] = []
+        for iota, spec in enumerate(specs):
+            if not spec or spec[0].kind != 'ident':
+                continue
+            first = spec[0] if grouped else decl[0]
+            if keyword != 'type':
+                names, value_type, exprs = self._value_spec(spec)
+                if keyword == 'const' and not exprs:
+                    # 省略表达式的常量重复上一个表达式列表及其类型，iota 为 spec 在分组中的序号
+                    exprs, value_type = last_exprs, last_type
+                elif keyword == 'const':
+                    last_exprs, last_type = exprs, value_type
+                symbols.extend(self._parse_value_spec(keyword, names, value_type, exprs, first, spec[-1],
+                                                      scanner, iota, consts))
+                continue
+            extra = {}
+            kind = self._type_kind(spec)
+            extra['is_alias'] = self._is_alias(spec)
+            if self._type_name_end(spec) > 1:
+                extra['type_params'] = self._parse_type_params(spec[2:self._type_name_end(spec) - 1])
+            if kind == Kind.STRUCT:
+                extra['fields'] = self._parse_struct_fields(spec, scanner)
+            elif kind == Kind.INTERFACE:
+                extra['methods'], extra['embeds'] = self._parse_interface(spec)
+            # 字段名与接口方法名是声明而非引用
+            declared = {f.name for f in extra.get('fields', [])} | {m.name for m in extra.get('methods', [])}
+            extra['references'] = [r for r in self._references(spec[1:], spec[0].value) if r not in declared]
+            symbols.append(self._make_symbol(spec[0].value, kind, first, spec[-1], scanner, **extra))
+        return symbols
+
+    def _parse_value_spec(self, keyword: str, names: List[Token], value_type: List[Token],
+                          exprs: List[List[Token]], first: Token, last: Token, scanner: GoScanner,
+                          iota: int, consts: Dict[str, Value]) -> List[SymbolInfo]:
+        """为 const / var spec（a, b T = x, y）中的每个名称各生成一个符号
+
+        名称与表达式按位置一一对应；var a, b = f() 这样只有一个多值表达式时，各名称共用它，
+        类型无法推断。符号从各自的名称开始（first 为第一个名称的起点，非分组声明时是关键字），
+        到 spec 的最后一个 token（last）结束。
+        """
+        symbols = []
+        for idx, name in enumerate(names):
+            if len(exprs) == len(names):
+                expr = exprs[idx]
+            else:
+                expr = exprs[0] if len(exprs) == 1 else []
+            shared = len(names) > 1 and len(exprs) == 1
+            extra = {'value_expr': render(expr, expr=True)}
+            if value_type:
+                extra['value_type'] = render(value_type)
+            elif not shared:
+                extra['value_type'] = _literal_type(expr)
+            if keyword == 'const':
+                kind = Kind.CONST
+                value = evaluate(expr, iota, consts)
+                if value is not None:
+                    consts[name.value] = value
+                    extra['value'] = format_value(value)
+            else:
+                kind = Kind.VAR
+            extra['references'] = [r for r in self._references(value_type + expr, name.value)
+                                   if r not in {n.value for n in names}]
+            start = first if idx == 0 else name
+            symbols.append(self._make_symbol(name.value, kind, start, last, scanner, **extra))
+        return symbols
+
+    @staticmethod
+    def _value_spec(spec: List[Token]) -> Tuple[List[Token], List[Token], List[List[Token]]]:
+        """拆分 const / var spec 为 (名称, 显式类型, 表达式列表)；类型或表达式省略时为空"""
+        j = 1
+        names = [spec[0]]
+        while j + 1 < len(spec) and spec[j].value == ',' and spec[j + 1].kind == 'ident':
+            names.append(spec[j + 1])
+            j += 2
+        depth = 0
+        for k in range(j, len(spec)):
+            tok = spec[k]
+            if tok.value in ('(', '[', '{'):
+                depth += 1
+            elif tok.value in (')', ']', '}'):
+                depth -= 1
+            elif depth == 0 and tok.kind == 'op' and tok.value == '=':
+                return names, spec[j:k], GoAnalyzer._split_commas(spec[k + 1:])
+        return names, spec[j:], []
+
+    @staticmethod
+    def _references(toks: List[Token], name: str) -> List[str]:
+        """收集声明中引用的标识符：跳过自身名称、选择器右侧（x.Y 的 Y）与键/标签（K: 的 K）"""
+        refs: List[str] = []
+        for j, tok in enumerate(toks):
+            if tok.kind != 'ident' or tok.value in (name, '_') or tok.value in refs:
+                continue
+            if j > 0 and toks[j - 1].value == '.':
+                continue
+            if j + 1 < len(toks) and toks[j + 1].value == ':':
+                continue
+            refs.append(tok.value)
+        return refs
+
+    def _parse_struct_fields(self, spec: List[Token], scanner: GoScanner) -> List[FieldInfo]:
+        """解析 struct { ... } 中的字段，支持多名字段、嵌入字段与标签"""
+        start = self._type_start(spec)
+        if start + 1 >= len(spec) or spec[start + 1].value != '{':
+            return []
+        body = spec[start + 2:self._matching(spec, start + 1)]
+        trailing = scanner.trailing_comments()
+
+        fields = []
+        for item in self._split_specs(body):
+            tag = ""
+            if len(item) > 1 and item[-1].kind == 'string':
+                tag = item[-1].value[1:-1]
+                item = item[:-1]
+            comment_tok = trailing.get(item[-1].line)
+            comment = self._comment_text(comment_tok.value) if comment_tok else ""
+
+            if self._is_embedded_field(item):
+                fields.append(FieldInfo(name="", type=render(item), tag=tag, embedded=True,
+                                        line=item[0].line, comment=comment))
+                continue
+
+            names = [item[0]]
+            i = 1
+            while i + 1 < len(item) and item[i].value == ',':
+                names.append(item[i + 1])
+                i += 2
+            type_str = render(item[i:])
+            for name in names:
+                fields.append(FieldInfo(name=name.value, type=type_str, tag=tag,
+                                        line=name.line, comment=comment))
+        return fields
+
+    def _parse_interface(self, spec: List[Token]) -> Tuple[List[MethodSig], List[str]]:
+        """解析 interface { ... }，返回 (方法, 嵌入的接口/类型约束)"""
+        start = self._type_start(spec)
+        if start + 1 >= len(spec) or spec[start + 1].value != '{':
+            return [], []
+        body = spec[start + 2:self._matching(spec, start + 1)]
+
+        methods, embeds = [], []
+        for item in self._split_specs(body):
+            if len(item) > 1 and item[0].kind == 'ident' and item[1].value == '(':
+                close = self._matching(item, 1)
+                methods.append(MethodSig(
+                    name=item[0].value,
+                    parameters=self._parse_params(item[2:close]),
+                    results=self._parse_results(item[close + 1:]),
+                    line=item[0].line,
+                ))
+            else:
+                embeds.append(render(item))
+        return methods, embeds
+
+    def _parse_params(self, toks: List[Token]) -> List[str]:
+        """解析参数列表（括号内部），把 a, b int 展开为 ["a int", "b int"]"""
+        return [f"{name} {type_str}" if name else type_str for name, type_str in self._param_pairs(toks)]
+
+    def _parse_type_params(self, toks: List[Token]) -> List[TypeParam]:
+        """解析类型参数列表（方括号内部），如 K comparable, V any"""
+        return [TypeParam(name=name, constraint=constraint) for name, constraint in self._param_pairs(toks)]
+
+    def _param_pairs(self, toks: List[Token]) -> List[Tuple[str, str]]:
+        """把参数列表拆成 (名称, 类型)；未命名参数的名称为空，共享类型的名称各自展开"""
+        groups = self._split_commas(toks)
+        if not any(self._has_param_name(g) for g in groups):
+            return [("", render(g)) for g in groups]
+
+        pairs, pending = [], []
+        for g in groups:
+            if self._has_param_name(g):
+                type_str = render(g[1:])
+                pairs.extend((name, type_str) for name in pending + [g[0].value])
+                pending = []
+            else:
+                pending.append(render(g))
+        return pairs + [("", p) for p in pending]
+
+    def _parse_results(self, toks: List[Token]) -> List[str]:
+        """解析返回值：单个类型或括号包裹的列表"""
+        if not toks:
+            return []
+        if toks[0].value == '(' and self._matching(toks, 0) == len(toks) - 1:
+            return self._parse_params(toks[1:-1])
+        return [render(toks)]
+
+    def _has_param_name(self, group: List[Token]) -> bool:
+        """参数组是否为 "名称 类型" 形式（而非单独的类型）"""
+        return len(group) > 1 and group[0].kind == 'ident' and not self._is_embedded_field(group)
+
+    @staticmethod
+    def _split_commas(toks: List[Token]) -> List[List[Token]]:
+        """按顶层逗号拆分 token 序列"""
+        groups, current, depth = [], [], 0
+        for tok in toks:
+            if tok.kind == 'op':
+                if tok.value in ('(', '[', '{'):
+                    depth += 1
+                elif tok.value in (')', ']', '}'):
+                    depth -= 1
+                elif depth == 0 and tok.value == ',':
+                    groups.append(current)
+                    current = []
+                    continue
+                elif depth == 0 and _is_semicolon(tok):
+                    continue
+            current.append(tok)
+        if current:
+            groups.append(current)
+        return [g for g in groups if g]
+
+    def _expand_embedded(self, symbols: List[SymbolInfo]):
+        """把嵌入的、在同一文件中定义的接口展开为其方法；无法解析的保留在 embeds 中"""
+        interfaces = {s.name: s for s in symbols if s.type == Kind.INTERFACE}
+
+        def collect(sym: SymbolInfo, seen: set) -> Tuple[List[MethodSig], List[str]]:
+            methods, embeds = list(sym.methods), []
+            for name in sym.embeds:
+                target = interfaces.get(name)
+                if target is None or name in seen:
+                    embeds.append(name)
+                    continue
+                sub_methods, sub_embeds = collect(target, seen | {name})
+                methods.extend(m for m in sub_methods if m.name not in {x.name for x in methods})
+                embeds.extend(e for e in sub_embeds if e not in embeds)
+            return methods, embeds
+
+        expanded = {name: collect(sym, {name}) for name, sym in interfaces.items()}
+        for name, (methods, embeds) in expanded.items():
+            interfaces[name].methods, interfaces[name].embeds = methods, embeds
+
+    def _is_embedded_field(self, item: List[Token]) -> bool:
+        """嵌入字段：T、*T、pkg.T、T[X]（类型实参后没有其他 token）"""
+        if item[0].value == '*':
+            return True
+        if item[0].kind != 'ident':
+            return False
+        if len(item) == 1 or item[1].value == '.':
+            return True
+        if item[1].value == '[':
+            return self._matching(item, 1) == len(item) - 1
+        return False
+
+    @staticmethod
+    def _type_name_end(spec: List[Token]) -> int:
+        """类型名及类型参数之后的下标"""
+        i = 1
+        # 跳过类型参数 [T any]；[N]int 之类的数组类型不跳过
+        if i + 1 < len(spec) and spec[i].value == '[' and spec[i + 1].kind == 'ident' \
+                and i + 2 < len(spec) and spec[i + 2].value != ']':
+            i = GoAnalyzer._matching(spec, i) + 1
+        return i
+
+    @staticmethod
+    def _is_alias(spec: List[Token]) -> bool:
+        """type A = B 形式的别名（对应 go/ast 中 TypeSpec.Assign 非零）"""
+        i = GoAnalyzer._type_name_end(spec)
+        return i < len(spec) and spec[i].value == '='
+
+    @staticmethod
+    def _type_start(spec: List[Token]) -> int:
+        """类型定义右侧类型表达式的起始下标"""
+        i = GoAnalyzer._type_name_end(spec)
+        if i < len(spec) and spec[i].value == '=':
+            i += 1
+        return i
+
+    @staticmethod
+    def _type_kind(spec: List[Token]) -> Kind:
+        """根据类型定义右侧判断种类：别名优先，其次按首个 token 区分 struct / interface / func"""
+        if GoAnalyzer._is_alias(spec):
+            return Kind.ALIAS
+        i = GoAnalyzer._type_start(spec)
+        if i < len(spec) and spec[i].value in ('struct', 'interface', 'func'):
+            return {'struct': Kind.STRUCT, 'interface': Kind.INTERFACE, 'func': Kind.FUNC_TYPE}[spec[i].value]
+        return Kind.TYPE
+
+    @staticmethod
+    def _split_specs(toks: List[Token]) -> List[List[Token]]:
+        """按顶层分号拆分分组声明中的各个 spec"""
+        specs, current, depth = [], [], 0
+        for tok in toks:
+            if tok.kind == 'op':
+                if tok.value in ('(', '[', '{'):
+                    depth += 1
+                elif tok.value in (')', ']', '}'):
+                    depth -= 1
+                elif depth == 0 and _is_semicolon(tok):
+                    if current:
+                        specs.append(current)
+                    current = []
+                    continue
+            current.append(tok)
+        if current:
+            specs.append(current)
+        return specs
+
+    @staticmethod
+    def _matching(toks: List[Token], start: int) -> int:
+        """返回与 toks[start] 处左括号匹配的右括号下标；未闭合时返回最后一个下标"""
+        pairs = {'(': ')', '[': ']', '{': '}'}
+        opening = toks[start].value
+        closing = pairs[opening]
+        depth = 0
+        for j in range(start, len(toks)):
+            if toks[j].kind != 'op':
+                continue
+            if toks[j].value == opening:
+                depth += 1
+            elif toks[j].value == closing:
+                depth -= 1
+                if depth == 0:
+                    return j
+        return len(toks) - 1
+
+    def _extract_doc(self, lines_list: List[str], decl_line: int) -> Tuple[str, int]:
+        """提取紧邻声明上方的注释组作为文档（与 go/ast 的 Doc 语义一致）
+
+        返回 (文档文本, 注释组首行行号)；没有文档时为 ("", 0)。
+
+        - 支持连续的 // 行注释与独占行的 /* */ 块注释
+        - 注释与声明之间隔有空行时视为游离注释，不作为文档
+        - 以代码开头、行尾带注释的行（如结构体字段的行尾注释）不属于注释组
+        - //go:noinline、//line、//export 等指令行不计入文档文本
+        """
+        groups: List[List[str]] = []
+        idx = decl_line - 2  # 声明上一行（0-based）
+
+        while idx >= 0:
+            stripped = lines_list[idx].strip()
+            if stripped.startswith('//'):
+                # 指令行仍属于注释组，但不计入文档文本
+                groups.append([] if _DIRECTIVE.match(stripped) else [self._strip_line_comment(stripped)])
+                idx -= 1
+            elif stripped.endswith('*/'):
+                # 向上寻找块注释的起始行
+                start = idx
+                while start >= 0 and '/*' not in lines_list[start]:
+                    start -= 1
+                if start < 0 or not lines_list[start].strip().startswith('/*'):
+                    break
+                block = '\n'.join(l.strip() for l in lines_list[start:idx + 1])
+                groups.append(self._strip_block_comment(block))
+                idx = start - 1
+            else:
+                break
+
+        if not groups:
+            return "", 0
+
+        doc_lines = [l for group in reversed(groups) for l in group]
+        # 去除首尾空行
+        while doc_lines and not doc_lines[0].strip():
+            doc_lines.pop(0)
+        while doc_lines and not doc_lines[-1].strip():
+            doc_lines.pop()
+        return '\n'.join(doc_lines), idx + 2
+
+    def _comment_text(self, text: str) -> str:
+        """单个注释 token 的文本（去掉注释标记）"""
+        if text.startswith('//'):
+            return "" if _DIRECTIVE.match(text) else self._strip_line_comment(text)
+        return '\n'.join(self._strip_block_comment(text)).strip()
+
+    @staticmethod
+    def _strip_line_comment(text: str) -> str:
+        """去掉 // 标记及其后的一个空格"""
+        text = text[2:]
+        if text.startswith(' '):
+            text = text[1:]
+        return text.rstrip()
+
+    @staticmethod
+    def _strip_block_comment(text: str) -> List[str]:
+        """去掉 /* */ 标记，以及每行开头可选的 * 装饰"""
+        body = text[2:-2]
+        result = []
+        for line in body.split('\n'):
+            line = line.strip()
+            if line.startswith('* ') or line == '*':
+                line = line[2:]
+            result.append(line.rstrip())
+        return result
@@ -0,0 +1,193 @@
+import re
+from dataclasses import dataclass, field
+from typing import List, Optional, Set, Tuple
+
+# 与 go/build 的 syslist.go 一致
+KNOWN_OS = {
+    'aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'js',
+    'linux', 'nacl', 'netbsd', 'openbsd', 'plan9', 'solaris', 'wasip1', 'windows', 'zos',
+}
+KNOWN_ARCH = {
+    '386', 'amd64', 'amd64p32', 'arm', 'armbe', 'arm64', 'arm64be', 'loong64', 'mips',
+    'mipsle', 'mips64', 'mips64le', 'mips64p32', 'mips64p32le', 'ppc', 'ppc64', 'ppc64le',
+    'riscv', 'riscv64', 's390', 's390x', 'sparc', 'sparc64', 'wasm',
+}
+UNIX_OS = {
+    'aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'linux',
+    'netbsd', 'openbsd', 'solaris',
+}
+# 派生系统同时满足其基础系统的约束
+IMPLIED_OS = {'android': 'linux', 'illumos': 'solaris', 'ios': 'darwin'}
+
+_GO_VERSION = re.compile(r'go1\.([0-9]+)')
+_EXPR_TOKEN = re.compile(r'\s*(&&|\|\||[()!]|[A-Za-z0-9_.]+)')
+
+
+@dataclass
+class BuildContext:
+    """构建上下文：对应 go/build.Context 中影响文件选择的部分
+
+    go_version 决定满足哪些 go1.N 发布标签（go1.1 到该版本都成立）。
+    """
+    goos: str = 'linux'
+    goarch: str = 'amd64'
+    tags: List[str] = field(default_factory=list)
+    cgo: bool = False
+    go_version: str = 'go1.22'
+
+    def _satisfied(self) -> Set[str]:
+        tags = {self.goos, self.goarch, 'gc', *self.tags}
+        if self.cgo:
+            tags.add('cgo')
+        if self.goos in UNIX_OS:
+            tags.add('unix')
+        if self.goos in IMPLIED_OS:
+            tags.add(IMPLIED_OS[self.goos])
+        return tags
+
+    def match_tag(self, tag: str) -> bool:
+        m = _GO_VERSION.fullmatch(tag)
+        if m:
+            current = _GO_VERSION.fullmatch(self.go_version)
+            return current is not None and int(m.group(1)) <= int(current.group(1))
+        return tag in self._satisfied()
+
+    def match_os(self, goos: str) -> bool:
+        return goos == self.goos or goos == IMPLIED_OS.get(self.goos)
+
+    def match_file_name(self, name: str) -> bool:
+        """按文件名后缀 _GOOS、_GOARCH、_GOOS_GOARCH（可再跟 _test）判断"""
+        stem = name[:-3] if name.endswith('.go') else name
+        if stem.endswith('_test'):
+            stem = stem[:-5]
+        i = stem.find('_')
+        if i < 0:
+            return True
+        parts = stem[i:].split('_')
+        if len(parts) >= 2 and parts[-2] in KNOWN_OS and parts[-1] in KNOWN_ARCH:
+            return self.match_os(parts[-2]) and parts[-1] == self.goarch
+        if parts[-1] in KNOWN_OS:
+            return self.match_os(parts[-1])
+        if parts[-1] in KNOWN_ARCH:
+            return parts[-1] == self.goarch
+        return True
+
+    def match(self, name: str, src: str) -> bool:
+        """文件名与文件头部的构建约束是否都满足；约束格式错误时抛出 ValueError"""
+        if not self.match_file_name(name):
+            return False
+        go_build, plus_build = read_constraints(src)
+        if go_build is not None:
+            return _Expr(go_build).evaluate(self.match_tag)
+        # 多行 // +build 之间为与，行内空格为或，逗号为与
+        return all(
+            any(all(self._match_term(t) for t in option.split(',')) for option in line.split())
+            for line in plus_build
+        )
+
+    def _match_term(self, term: str) -> bool:
+        if term.startswith('!'):
+            return not self.match_tag(term[1:])
+        return self.match_tag(term)
+
+
+def read_constraints(src: str) -> Tuple[Optional[str], List[str]]:
+    """读取 package 子句之前的构建约束，返回 (//go:build 表达式或 None, // +build 各行内容)
+
+    与 go/build 一致：// +build 行只有在其所在的注释块后跟空行时才生效。
+    """
+    go_build: Optional[str] = None
+    plus_build: List[str] = []
+    pending: List[str] = []
+    in_block = False
+    if src.startswith('\ufeff'):
+        src = src[1:]
+    for raw in src.splitlines():
+        line = raw.strip()
+        if in_block:
+            if '*/' in line:
+                in_block = False
+            continue
+        if not line:
+            plus_build.extend(pending)
+            pending = []
+            continue
+        if line.startswith('/*'):
+            in_block = '*/' not in line[2:]
+            continue
+        if not line.startswith('//'):
+            break
+        text = line[2:]
+        words = text.split()
+        if text.startswith('go:build') and words[0] == 'go:build':
+            if go_build is None:
+                go_build = ' '.join(words[1:])
+        elif words and words[0] == '+build':
+            pending.append(' '.join(words[1:]))
+    return go_build, plus_build
+
+
+class _Expr:
+    """//go:build 表达式：|| 优先级低于 &&，! 为一元运算，支持括号"""
+
+    def __init__(self, text: str):
+        self.text = text
+        self.tokens: List[str] = []
+        pos = 0
+        while pos < len(text):
+            m = _EXPR_TOKEN.match(text, pos)
+            if not m:
+                if text[pos:].strip():
+                    raise ValueError(f"invalid //go:build expression: {text!r}")
+                break
+            self.tokens.append(m.group(1))
+            pos = m.end()
+        self.pos = 0
+
+    def evaluate(self, match_tag) -> bool:
+        self.match_tag = match_tag
+        self.pos = 0
+        result = self._or()
+        if self.pos != len(self.tokens):
+            raise ValueError(f"invalid //go:build expression: {self.text!r}")
+        return result
+
+    def _peek(self) -> str:
+        return self.tokens[self.pos] if self.pos < len(self.tokens) else ''
+
+    def _or(self) -> bool:
+        result = self._and()
+        while self._peek() == '||':
+            self.pos += 1
+            # 不短路，保证整个表达式都被语法检查
+            rhs = self._and()
+            result = result or rhs
+        return result
+
+    def _and(self) -> bool:
+        result = self._not()
+        while self._peek() == '&&':
+            self.pos += 1
+            rhs = self._not()
+            result = result and rhs
+        return result
+
+    def _not(self) -> bool:
+        if self._peek() == '!':
+            self.pos += 1
+            return not self._not()
+        return self._atom()
+
+    def _atom(self) -> bool:
+        tok = self._peek()
+        if tok == '(':
+            self.pos += 1
+            result = self._or()
+            if self._peek() != ')':
+                raise ValueError(f"invalid //go:build expression: {self.text!r}")
+            self.pos += 1
+            return result
+        if not tok or tok in (')', '&&', '||'):
+            raise ValueError(f"invalid //go:build expression: {self.text!r}")
+        self.pos += 1
+        return self.match_tag(tok)
@@ -0,0 +1,184 @@
+from typing import Dict, List, Optional, Union
+
+from .go_scanner import Token
+
+Value = Union[int, float, str]
+
+# 二元运算符优先级（Go 规范 "Operator precedence"），比较与逻辑运算不求值
+_PRECEDENCE = {
+    '*': 5, '/': 5, '%': 5, '<<': 5, '>>': 5, '&': 5, '&^': 5,
+    '+': 4, '-': 4, '|': 4, '^': 4,
+}
+
+
+class _Unknown(Exception):
+    """表达式无法静态求值"""
+
+
+def evaluate(toks: List[Token], iota: int, env: Dict[str, Value]) -> Optional[Value]:
+    """对常量表达式求值；含函数调用、类型转换、未知标识符等无法确定的情况返回 None
+
+    env 为此前已求值的同文件常量。整数按任意精度计算（与 Go 的无类型常量一致），
+    字符串只支持字面量与 + 拼接。
+    """
+    try:
+        parser = _Parser(toks, iota, env)
+        value = parser.binary(1)
+        if parser.pos != len(toks):
+            return None
+        return value
+    except (_Unknown, ZeroDivisionError, ValueError, OverflowError):
+        return None
+
+
+def format_value(value: Value) -> str:
+    """按 Go 字面量的写法格式化求值结果"""
+    if isinstance(value, str):
+        return '"' + value.replace('\\', '\\\\').replace('"', '\\"').replace('\n', '\\n').replace('\t', '\\t') + '"'
+    if isinstance(value, float):
+        return repr(value)
+    return str(value)
+
+
+def _parse_int(text: str) -> int:
+    text = text.replace('_', '')
+    if len(text) > 1 and text[0] == '0' and text[1].isdigit():
+        return int(text, 8)
+    return int(text, 0)
+
+
+_ESCAPES = {'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', "'": "'", '"': '"',
+            'a': '\a', 'b': '\b', 'f': '\f', 'v': '\v'}
+
+
+def _unquote(text: str) -> str:
+    if text.startswith('`'):
+        return text[1:-1].replace('\r', '')
+    body = text[1:-1]
+    if '\\' not in body:
+        return body
+    out, i = [], 0
+    while i < len(body):
+        ch = body[i]
+        if ch != '\\':
+            out.append(ch)
+            i += 1
+            continue
+        esc = body[i + 1:i + 2]
+        if esc in ('x', 'u', 'U'):
+            width = {'x': 2, 'u': 4, 'U': 8}[esc]
+            out.append(chr(int(body[i + 2:i + 2 + width], 16)))
+            i += 2 + width
+        elif len(body[i + 1:i + 4]) == 3 and all(c in '01234567' for c in body[i + 1:i + 4]):
+            out.append(chr(int(body[i + 1:i + 4], 8)))
+            i += 4
+        elif esc in _ESCAPES:
+            out.append(_ESCAPES[esc])
+            i += 2
+        else:
+            raise ValueError(f"invalid escape: \\{esc}")
+    return ''.join(out)
+
+
+class _Parser:
+    def __init__(self, toks: List[Token], iota: int, env: Dict[str, Value]):
+        self.toks = toks
+        self.iota = iota
+        self.env = env
+        self.pos = 0
+
+    def _peek(self) -> Optional[Token]:
+        return self.toks[self.pos] if self.pos < len(self.toks) else None
+
+    def binary(self, min_prec: int) -> Value:
+        left = self.unary()
+        while True:
+            tok = self._peek()
+            prec = _PRECEDENCE.get(tok.value, 0) if tok is not None and tok.kind == 'op' else 0
+            if prec < min_prec:
+                return left
+            self.pos += 1
+            left = self._apply(tok.value, left, self.binary(prec + 1))
+
+    def unary(self) -> Value:
+        tok = self._peek()
+        if tok is not None and tok.kind == 'op' and tok.value in ('-', '+', '^'):
+            self.pos += 1
+            operand = self.unary()
+            if isinstance(operand, str) or (tok.value == '^' and not isinstance(operand, int)):
+                raise _Unknown
+            return {'-': lambda v: -v, '+': lambda v: v, '^': lambda v: ~v}[tok.value](operand)
+        return self.primary()
+
+    def primary(self) -> Value:
+        tok = self._peek()
+        if tok is None:
+            raise _Unknown
+        self.pos += 1
+        if tok.kind == 'int':
+            return _parse_int(tok.value)
+        if tok.kind == 'float':
+            return float(tok.value.replace('_', '')) if not tok.value.lower().startswith('0x') \
+                else float.fromhex(tok.value.replace('_', ''))
+        if tok.kind == 'string':
+            return _unquote(tok.value)
+        if tok.kind == 'char':
+            text = _unquote(tok.value)
+            if len(text) != 1:
+                raise _Unknown
+            return ord(text)
+        if tok.kind == 'ident':
+            nxt = self._peek()
+            # 函数调用、类型转换与选择器都无法静态确定
+            if nxt is not None and nxt.value in ('(', '.', '['):
+                raise _Unknown
+            if tok.value == 'iota':
+                return self.iota
+            if tok.value in self.env:
+                return self.env[tok.value]
+            raise _Unknown
+        if tok.value == '(':
+            value = self.binary(1)
+            close = self._peek()
+            if close is None or close.value != ')':
+                raise _Unknown
+            self.pos += 1
+            return value
+        raise _Unknown
+
+    @staticmethod
+    def _apply(op: str, a: Value, b: Value) -> Value:
+        if isinstance(a, str) or isinstance(b, str):
+            if op == '+' and isinstance(a, str) and isinstance(b, str):
+                return a + b
+            raise _Unknown
+        if op == '+':
+            return a + b
+        if op == '-':
+            return a - b
+        if op == '*':
+            return a * b
+        if op == '/':
+            if isinstance(a, int) and isinstance(b, int):
+                # Go 的整数除法向零截断
+                q = abs(a) // abs(b)
+                return q if (a >= 0) == (b >= 0) else -q
+            return a / b
+        if not (isinstance(a, int) and isinstance(b, int)):
+            raise _Unknown
+        if op == '%':
+            r = abs(a) % abs(b)
+            return r if a >= 0 else -r
+        if op in ('<<', '>>'):
+            if b < 0 or b > 4096:
+                raise _Unknown
+            return a << b if op == '<<' else a >> b
+        if op == '&':
+            return a & b
+        if op == '|':
+            return a | b
+        if op == '^':
+            return a ^ b
+        if op == '&^':
+            return a & ~b
+        raise _Unknown
@@ -0,0 +1,196 @@
+import bisect
+import re
+from dataclasses import dataclass
+from typing import Dict, List, Optional, Tuple
+
+KEYWORDS = {
+    'break', 'case', 'chan', 'const', 'continue', 'default', 'defer', 'else',
+    'fallthrough', 'for', 'func', 'go', 'goto', 'if', 'import', 'interface',
+    'map', 'package', 'range', 'return', 'select', 'struct', 'switch', 'type', 'var',
+}
+
+BOM = '\ufeff'
+
+# 按长度降序排列，保证最长匹配
+OPERATORS = [
+    '<<=', '>>=', '&^=', '...',
+    '&&', '||', '<-', '++', '--', '==', '!=', '<=', '>=', ':=',
+    '+=', '-=', '*=', '/=', '%=', '&=', '|=', '^=', '<<', '>>', '&^',
+    '+', '-', '*', '/', '%', '&', '|', '^', '<', '>', '=', '!', '~',
+    '(', ')', '[', ']', '{', '}', ',', ';', '.', ':',
+]
+
+# 行尾需要自动插入分号的 token（Go 规范 "Semicolons" 一节）
+_ASI_KEYWORDS = {'break', 'continue', 'fallthrough', 'return'}
+_ASI_OPERATORS = {'++', '--', ')', ']', '}'}
+_ASI_KINDS = {'ident', 'int', 'float', 'imag', 'char', 'string'}
+
+_NUMBER = re.compile(
+    r'0[xX][0-9a-fA-F_]*(?:\.[0-9a-fA-F_]*)?(?:[pP][+-]?[0-9_]+)?i?'
+    r'|0[bB][01_]+i?'
+    r'|0[oO][0-7_]+i?'
+    r'|(?:[0-9][0-9_]*(?:\.[0-9_]*)?|\.[0-9][0-9_]*)(?:[eE][+-]?[0-9_]+)?i?'
+)
+
+
+@dataclass
+class Token:
+    """词法单元"""
+    kind: str  # 'ident', 'keyword', 'int', 'float', 'imag', 'char', 'string', 'op', 'comment', 'illegal'
+    value: str
+    offset: int  # 起始偏移
+    end: int  # 结束偏移（不含）
+    line: int
+    column: int  # 从 1 开始
+
+
+class GoScanner:
+    """Go 词法扫描器
+
+    与 go/scanner 一致地处理字符串、原始字符串、rune、注释与自动分号插入；
+    自动插入的分号 value 为 '\\n'，便于与源码中的显式分号区分。
+    """
+
+    def __init__(self, src: str):
+        self.src = src
+        self.line_starts = [0] + [m.end() for m in re.finditer('\n', src)]
+        # 开头的 UTF-8 BOM 不属于任何 token，第一行从它之后开始，使列号与编辑器显示一致
+        self.bom = src.startswith(BOM)
+        if self.bom:
+            self.line_starts[0] = len(BOM)
+        self.tokens: List[Token] = []
+        self._trailing: Optional[Dict[int, Token]] = None
+        self._scan()
+
+    def position(self, offset: int) -> Tuple[int, int]:
+        """偏移量转换为 (行, 列)，均从 1 开始"""
+        line = bisect.bisect_right(self.line_starts, offset)
+        return line, offset - self.line_starts[line - 1] + 1
+
+    def code_tokens(self) -> List[Token]:
+        """去除注释后的 token 序列"""
+        return [t for t in self.tokens if t.kind != 'comment']
+
+    def trailing_comments(self) -> Dict[int, Token]:
+        """行尾注释：同一行前面已有代码的注释，以行号为键"""
+        if self._trailing is not None:
+            return self._trailing
+        result: Dict[int, Token] = {}
+        last_code_line = 0
+        for tok in self.tokens:
+            if tok.kind != 'comment':
+                if tok.value != '\n':
+                    last_code_line = tok.line
+            elif tok.line == last_code_line and tok.line not in result:
+                result[tok.line] = tok
+        self._trailing = result
+        return result
+
+    def _emit(self, kind: str, start: int, end: int, value: Optional[str] = None):
+        line, column = self.position(start)
+        self.tokens.append(Token(kind, self.src[start:end] if value is None else value, start, end, line, column))
+
+    def _needs_semicolon(self) -> bool:
+        for tok in reversed(self.tokens):
+            if tok.kind == 'comment':
+                continue
+            if tok.kind in _ASI_KINDS:
+                return True
+            if tok.kind == 'keyword':
+                return tok.value in _ASI_KEYWORDS
+            if tok.kind == 'op':
+                return tok.value in _ASI_OPERATORS
+            return False
+        return False
+
+    def _scan(self):
+        src = self.src
+        n = len(src)
+        i = len(BOM) if self.bom else 0
+        while i < n:
+            ch = src[i]
+
+            if ch == '\n':
+                if self._needs_semicolon():
+                    self._emit('op', i, i, '\n')
+                i += 1
+                continue
+
+            if ch in ' \t\r':
+                i += 1
+                continue
+
+            # 注释
+            if src.startswith('//', i):
+                end = src.find('\n', i)
+                end = n if end < 0 else end
+                # 行尾的 \r 不属于注释内容
+                text_end = end - 1 if end > i and src[end - 1] == '\r' else end
+                self._emit('comment', i, text_end)
+                i = end
+                continue
+            if src.startswith('/*', i):
+                end = src.find('*/', i + 2)
+                end = n if end < 0 else end + 2
+                # 含换行的块注释等同于换行
+                if '\n' in src[i:end] and self._needs_semicolon():
+                    self._emit('op', i, i, '\n')
+                self._emit('comment', i, end)
+                i = end
+                continue
+
+            # 标识符与关键字
+            if ch.isalpha() or ch == '_':
+                j = i + 1
+                while j < n and (src[j].isalnum() or src[j] == '_'):
+                    j += 1
+                word = src[i:j]
+                self._emit('keyword' if word in KEYWORDS else 'ident', i, j)
+                i = j
+                continue
+
+            # 数字
+            if ch.isdigit() or (ch == '.' and i + 1 < n and src[i + 1].isdigit()):
+                m = _NUMBER.match(src, i)
+                j = m.end() if m and m.end() > i else i + 1
+                text = src[i:j]
+                if text.endswith('i'):
+                    kind = 'imag'
+                elif not text.lower().startswith('0x') and ('.' in text or 'e' in text.lower()):
+                    kind = 'float'
+                elif text.lower().startswith('0x') and ('.' in text or 'p' in text.lower()):
+                    kind = 'float'
+                else:
+                    kind = 'int'
+                self._emit(kind, i, j)
+                i = j
+                continue
+
+            # 字符串与 rune
+            if ch in '"\'':
+                j = i + 1
+                while j < n and src[j] != ch and src[j] != '\n':
+                    j += 2 if src[j] == '\\' else 1
+                j = min(j + 1, n) if j < n and src[j] == ch else j
+                self._emit('string' if ch == '"' else 'char', i, j)
+                i = j
+                continue
+            if ch == '`':
+                end = src.find('`', i + 1)
+                j = n if end < 0 else end + 1
+                self._emit('string', i, j)
+                i = j
+                continue
+
+            # 运算符
+            for op in OPERATORS:
+                if src.startswith(op, i):
+                    self._emit('op', i, i + len(op))
+                    i += len(op)
+                    break
+            else:
+                self._emit('illegal', i, i + 1)
+                i += 1
+
+        if self._needs_semicolon():
+            self._emit('op', n, n, '\n')
@@ -0,0 +1,70 @@
+"""
+按查询对符号做相关性排序（BM25，无外部依赖）
+
+每个符号视为由三个字段组成的文档：名称、文档注释、签名。默认用
+identifiers.tokenize 把标识符按 camelCase / snake_case 拆分为小写词（同时保留
+整个标识符），因此查询 "parse struct" 能命中 ParseStruct、parse_struct 等写法。
+
+各字段分别计算 BM25 得分，再按 RankWeights 加权求和；IDF 在整个符号集合上
+统计。得分相同的符号按源码位置（文件、行、列）排序，位置也相同时保持输入顺序，
+保证结果确定。
+"""
+
+import math
+from collections import Counter
+from dataclasses import dataclass
+from typing import Callable, Iterable, List, Optional
+
+from .core import SymbolInfo
+from .identifiers import tokenize
+
+
+@dataclass
+class RankWeights:
+    """各字段的权重"""
+    name: float = 3.0
+    doc: float = 1.0
+    signature: float = 1.0
+
+
+@dataclass
+class ScoredSymbol:
+    """带得分的符号"""
+    symbol: SymbolInfo
+    score: float
+
+
+def rank(query: str, symbols: Iterable[SymbolInfo], weights: Optional[RankWeights] = None,
+         k1: float = 1.2, b: float = 0.75,
+         tokenizer: Callable[[str], List[str]] = tokenize) -> List[ScoredSymbol]:
+    """按与 query 的词法相关性对 symbols 降序排序，返回全部符号（可能得分为 0）
+
+    tokenizer 同时用于查询与符号的各字段。
+    """
+    weights = weights or RankWeights()
+    field_weights = [weights.name, weights.doc, weights.signature]
+    symbols = list(symbols)
+    docs = [[Counter(tokenizer(text)) for text in (s.name, s.docstring, s.signature)] for s in symbols]
+    terms = set(tokenizer(query))
+
+    n = len(docs)
+    lengths = [[sum(doc[i].values()) for doc in docs] for i in range(len(field_weights))]
+    avg_lengths = [sum(ls) / n if n else 0.0 for ls in lengths]
+    df = Counter(t for doc in docs for t in terms if any(t in f for f in doc))
+    idf = {t: math.log(1 + (n - df[t] + 0.5) / (df[t] + 0.5)) for t in terms}
+
+    scored: List[ScoredSymbol] = []
+    for idx, doc in enumerate(docs):
+        score = 0.0
+        for i, counts in enumerate(doc):
+            if not field_weights[i] or not avg_lengths[i]:
+                continue
+            norm = k1 * (1 - b + b * lengths[i][idx] / avg_lengths[i])
+            for t in terms:
+                tf = counts.get(t, 0)
+                if tf:
+                    score += field_weights[i] * idf[t] * tf * (k1 + 1) / (tf + norm)
+        scored.append(ScoredSymbol(symbols[idx], score))
+
+    # 得分相同时按 (文件, 行, 列) 排列；sorted 是稳定的：位置相同的符号保持输入顺序
+    return sorted(scored, key=lambda s: (-s.score, s.symbol.file, s.symbol.line, s.symbol.column))
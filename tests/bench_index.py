@@ -0,0 +1,59 @@
+"""
+Index 增量更新基准：对比单文件 update 与全量 analyze_dir 的耗时
+
+用法: python3 bench_index.py [包数] [每包文件数]
+"""
+import sys
+import tempfile
+import timeit
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.index import Index
+from analyzer.parsers.go import GoAnalyzer
+
+TEMPLATE = '''package pkg{p}
+
+import "fmt"
+
+// Type{f} 是示例类型。
+type Type{f} struct {{
+	Name  string `json:"name"`
+	Value int
+}}
+
+func (t *Type{f}) String() string {{
+	return fmt.Sprintf("%s=%d", t.Name, t.Value)
+}}
+
+func New{f}(name string) *Type{f} {{
+	return &Type{f}{{Name: name}}
+}}
+'''
+
+
+def main():
+    packages = int(sys.argv[1]) if len(sys.argv) > 1 else 20
+    per_package = int(sys.argv[2]) if len(sys.argv) > 2 else 25
+    with tempfile.TemporaryDirectory() as tmp:
+        root = Path(tmp)
+        for p in range(packages):
+            (root / f"pkg{p}").mkdir()
+            for f in range(per_package):
+                (root / f"pkg{p}" / f"file{f}.go").write_text(TEMPLATE.format(p=p, f=f))
+
+        analyzer = GoAnalyzer()
+        full = min(timeit.repeat(lambda: analyzer.analyze_dir(root), number=1, repeat=3))
+        index, _ = Index.build(root)
+        src = TEMPLATE.format(p=0, f=0).encode()
+        update = min(timeit.repeat(lambda: index.update("pkg0/file0.go", src), number=10, repeat=3)) / 10
+
+        print(f"files:       {packages * per_package}")
+        print(f"analyze_dir: {full * 1000:.2f} ms")
+        print(f"update:      {update * 1000:.3f} ms ({full / update:.0f}x faster)")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,30 @@
+package main
+
+// ConstVal 是一个常量。
+const ConstVal = 10
+
+// 这段注释与声明之间有空行，属于游离注释。
+
+var GlobalVar = "hello"
+
+/*
+MyInterface 定义了一个方法。
+支持多行块注释。
+*/
+type MyInterface interface {
+	Method()
+}
+
+// MyStruct 是一个结构体。
+//
+// 第二段说明。
+type MyStruct struct {
+	Field int // 字段的行尾注释
+}
+type Alias int // 行尾注释不是下一个声明的文档
+type StringAlias = string
+
+/* Function 原样返回参数。 */
+func Function(a int) int {
+	return a
+}
@@ -0,0 +1,110 @@
+import json
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.cache import ParseCache
+from analyzer.parsers.go import GoAnalyzer
+
+
+class CountingAnalyzer(GoAnalyzer):
+    """记录实际解析的文件"""
+
+    def __init__(self, **kwargs):
+        super().__init__(**kwargs)
+        self.parsed = []
+
+    def analyze_source(self, filename, src):
+        self.parsed.append(filename)
+        return super().analyze_source(filename, src)
+
+
+class TestParseCache(unittest.TestCase):
+    def setUp(self):
+        self.tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self.tmp.name) / "src"
+        self.cache_dir = Path(self.tmp.name) / "cache"
+        self.root.mkdir()
+        (self.root / "a.go").write_text("package x\n\n// A 文档。\nfunc A() {}\n")
+        (self.root / "b.go").write_text("package x\n\nfunc B() {}\n")
+        (self.root / "copy.go").write_text("package x\n\nfunc B() {}\n")
+
+    def tearDown(self):
+        self.tmp.cleanup()
+
+    def analyze(self, **kwargs):
+        analyzer = CountingAnalyzer(**kwargs)
+        results, errors = analyzer.analyze_dir(self.root, workers=1, cache_dir=self.cache_dir)
+        self.assertEqual(errors, [])
+        return analyzer, results
+
+    def entries(self):
+        return sorted(self.cache_dir.glob("*/*.json"))
+
+    def test_hit_and_miss(self):
+        first, expected = self.analyze()
+        # 内容相同的 b.go 与 copy.go 共享一个条目
+        self.assertEqual(sorted(first.parsed), ["a.go", "b.go"])
+        self.assertEqual(len(self.entries()), 2)
+
+        second, results = self.analyze()
+        self.assertEqual(second.parsed, [])
+        self.assertEqual(results, expected)
+        self.assertEqual(results["copy.go"].path, "copy.go")
+        self.assertEqual(results["a.go"].symbols[0].source(), "// A 文档。\nfunc A() {}")
+
+        # 修改内容后只重新解析该文件
+        (self.root / "b.go").write_text("package x\n\nfunc B2() {}\n")
+        third, results = self.analyze()
+        self.assertEqual(third.parsed, ["b.go"])
+        self.assertEqual(results["b.go"].symbols[0].name, "B2")
+
+        # 解析选项不同时不共用条目
+        fourth, _ = self.analyze(expand_embedded=True)
+        self.assertEqual(len(fourth.parsed), 3)
+
+    def test_bad_entries_are_reparsed(self):
+        self.analyze()
+        corrupt, newer = self.entries()
+        corrupt.write_text("{not json")
+        data = json.loads(newer.read_text())
+        data["schema_version"] = 99
+        newer.write_text(json.dumps(data))
+
+        analyzer, results = self.analyze()
+        self.assertEqual(sorted(analyzer.parsed), ["a.go", "b.go"])
+        self.assertEqual(sorted(results), ["a.go", "b.go", "copy.go"])
+        # 重新解析后的结果覆盖了坏条目
+        analyzer, _ = self.analyze()
+        self.assertEqual(analyzer.parsed, [])
+
+    def test_shared_entries_keep_diagnostic_paths(self):
+        broken = "package x\n\nfunc F() {\n"
+        (self.root / "broken_a.go").write_text(broken)
+        (self.root / "broken_b.go").write_text(broken)
+        for _ in range(2):
+            _, results = self.analyze()
+            for name in ("broken_a.go", "broken_b.go"):
+                [error] = results[name].errors
+                self.assertEqual([p.path for p in error.positions], [name])
+                self.assertTrue(str(error).startswith(f"{name}:4:1: error:"))
+
+    def test_clear_and_disable(self):
+        self.analyze()
+        ParseCache(self.cache_dir).clear()
+        self.assertFalse(self.cache_dir.exists())
+        analyzer, _ = self.analyze()
+        self.assertEqual(len(analyzer.parsed), 2)
+
+        # 不传 cache_dir 时不读写缓存
+        ParseCache(self.cache_dir).clear()
+        CountingAnalyzer().analyze_dir(self.root, workers=1)
+        self.assertFalse(self.cache_dir.exists())
+
+
+if __name__ == "__main__":
+    unittest.main()
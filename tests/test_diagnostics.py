@@ -0,0 +1,58 @@
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.diagnostics import ERROR, diagnostics
+from analyzer.parsers.go import GoAnalyzer
+from analyzer.parsers.go_build import BuildContext
+
+FILES = {
+    "a.go": "package x\n\nfunc init() {}\n\nfunc Helper() {}\n\ntype T struct{}\n\nfunc (T) M() {}\n\nvar _ = 1\n",
+    "b.go": "package x\n\nfunc init() {}\n\nconst Helper = 1\n\nfunc (t *T) M() {}\n\nvar _ = 2\n\nvar (\n\tv int\n\tv string\n)\n",
+    # 外部测试包与同目录的 x 包是不同的包
+    "a_test.go": "package x_test\n\nfunc Helper() {}\n",
+    "sub/c.go": "package x\n\nfunc Helper() {}\n",
+    "sys_linux.go": "package x\n\nfunc platform() {}\n",
+    "sys_windows.go": "package x\n\nfunc platform() {}\n",
+}
+
+
+class TestDiagnostics(unittest.TestCase):
+    def setUp(self):
+        self.tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self.tmp.name)
+        for rel, text in FILES.items():
+            (self.root / rel).parent.mkdir(parents=True, exist_ok=True)
+            (self.root / rel).write_text(text)
+
+    def tearDown(self):
+        self.tmp.cleanup()
+
+    def test_redeclared(self):
+        results, _ = GoAnalyzer().analyze_dir(self.root, build_context=BuildContext(goos="linux"))
+        self.assertEqual(results["a.go"].package, "x")
+        self.assertEqual(results["a_test.go"].package, "x_test")
+
+        diags = diagnostics(results)
+        self.assertEqual([(d.severity, d.code) for d in diags], [(ERROR, "redeclared")] * 3)
+        found = {d.message.split()[0]: [str(p) for p in d.positions] for d in diags}
+        self.assertEqual(found, {
+            "Helper": ["a.go:5:1", "b.go:5:1"],
+            "T.M": ["a.go:9:1", "b.go:7:1"],
+            "v": ["b.go:12:2", "b.go:13:2"],
+        })
+        self.assertEqual(str(diags[0]), "a.go:5:1: error: Helper redeclared in this package (also at b.go:5:1)")
+
+    def test_build_constraints(self):
+        # 不按构建约束过滤时，互斥的平台文件也会被报告
+        results, _ = GoAnalyzer().analyze_dir(self.root)
+        names = [d.message.split()[0] for d in diagnostics(results)]
+        self.assertIn("platform", names)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,84 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.core import FileAnalysis
+from analyzer.diff import body_hash, diff
+from analyzer.parsers.go import GoAnalyzer
+
+OLD = '''package demo
+
+// MyStruct 示例结构体。
+type MyStruct struct {
+	Field int
+}
+
+func Function(a int) int {
+	return a
+}
+
+func Removed() {}
+
+func Reformatted(a, b int) int { return a + b }
+'''
+
+NEW = '''package demo
+
+// MyStruct 示例结构体（注释改动不算修改）。
+type MyStruct struct {
+	Field int
+	Extra string
+}
+
+func Function(a int, b int) int {
+	return a
+}
+
+func Added() {}
+
+func Reformatted(a, b int) int {
+	return a+b
+}
+'''
+
+
+class TestDiff(unittest.TestCase):
+    def setUp(self):
+        analyzer = GoAnalyzer()
+        self.old = analyzer.analyze_source("demo.go", OLD)
+        self.new = analyzer.analyze_source("demo.go", NEW)
+
+    def test_diff(self):
+        result = diff(self.old, self.new)
+        self.assertEqual([s.name for s in result.added], ["Added"])
+        self.assertEqual([s.name for s in result.removed], ["Removed"])
+        changes = {c.new.name: c for c in result.modified}
+        self.assertEqual(sorted(changes), ["Function", "MyStruct", "Reformatted"])
+        self.assertEqual(changes["MyStruct"].aspects, ["body"])
+        self.assertEqual(changes["MyStruct"].added_members, ["Extra"])
+        self.assertEqual(changes["Function"].aspects, ["signature", "body"])
+        self.assertEqual(changes["Reformatted"].aspects, ["body"])
+        self.assertTrue(diff(self.old, self.old).is_empty())
+
+    def test_ignore_whitespace(self):
+        result = diff(self.old, self.new, ignore_whitespace=True)
+        self.assertEqual(sorted(c.new.name for c in result.modified), ["Function", "MyStruct"])
+        old = self.old.lookup("Reformatted")
+        self.assertNotEqual(body_hash(old), body_hash(self.new.lookup("Reformatted")))
+        self.assertEqual(body_hash(old, True), body_hash(self.new.lookup("Reformatted"), True))
+
+    def test_diff_without_source(self):
+        # 从 JSON 还原后没有源码，退回比较成员结构
+        old = FileAnalysis.from_json(self.old.to_json())
+        new = FileAnalysis.from_json(self.new.to_json())
+        self.assertEqual(body_hash(old.symbols[0]), "")
+        changes = {c.new.name: c for c in diff(old, new).modified}
+        self.assertEqual(sorted(changes), ["Function", "MyStruct"])
+        self.assertEqual(changes["MyStruct"].added_members, ["Extra"])
+
+
+if __name__ == "__main__":
+    unittest.main()
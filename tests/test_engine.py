@@ -0,0 +1,61 @@
+import contextlib
+import io
+import json
+import shutil
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.engine import ProjectAnalyzer
+from analyzer.parsers.go import GoAnalyzer
+
+
+class TestProjectAnalyzer(unittest.TestCase):
+    def setUp(self):
+        self.tmp = tempfile.TemporaryDirectory()
+        self.root = Path(self.tmp.name)
+        shutil.copy(Path(__file__).parent / "codes" / "demo.go", self.root / "demo.go")
+
+    def tearDown(self):
+        self.tmp.cleanup()
+
+    def analyze(self, analyzer: ProjectAnalyzer) -> dict:
+        with contextlib.redirect_stderr(io.StringIO()):
+            analyzer.analyze()
+        return analyzer.to_dict()
+
+    def test_to_dict_excludes_source(self):
+        analyzer = ProjectAnalyzer(str(self.root))
+        data = self.analyze(analyzer)
+        [file] = data["files"]
+        self.assertEqual(file["path"], "demo.go")
+        # 符号的 file 与所在文件的相对路径一致
+        self.assertEqual({sym["file"] for sym in file["symbols"]}, {"demo.go"})
+        self.assertNotIn("source", file)
+        for sym in file["symbols"]:
+            self.assertNotIn("_source", sym)
+        # 只包含 dataclass 字段；lookup 的索引不随报告输出
+        analyzer.result.files[0].lookup("Function")
+        self.assertNotIn("_by_key", analyzer.to_dict()["files"][0])
+        # 报告中不含文件原文
+        self.assertNotIn("package main", json.dumps(data, ensure_ascii=False))
+        # 分析结束后不再保留源码
+        self.assertEqual(analyzer.result.files[0].source, "")
+
+    def test_to_dict_excludes_tokens(self):
+        analyzer = ProjectAnalyzer(str(self.root))
+        analyzer.go_analyzer = GoAnalyzer(retain_tokens=True)
+        data = self.analyze(analyzer)
+        [file] = data["files"]
+        self.assertNotIn("scanner", file)
+        for sym in file["symbols"]:
+            self.assertNotIn("_node", sym)
+        self.assertIsNotNone(analyzer.result.files[0].scanner)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,105 @@
+import io
+import json
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.core import FileAnalysis
+from analyzer.formatter import DelimitedRenderer, JSONRenderer, MarkdownRenderer, Renderer, SignatureRenderer, render
+from analyzer.parsers.go import GoAnalyzer
+
+
+class NamesRenderer(Renderer):
+    """只输出符号名的自定义渲染器"""
+
+    def render(self, fp, analysis):
+        fp.write(",".join(s.name for s in analysis.symbols))
+
+
+class TestRenderers(unittest.TestCase):
+    def setUp(self):
+        codes_dir = Path(__file__).parent / "codes"
+        self.result = GoAnalyzer().analyze(codes_dir / "demo.go")
+
+    def test_markdown(self):
+        text = render(self.result, MarkdownRenderer())
+        self.assertTrue(text.startswith(f"## {self.result.path} (Go)\n"))
+        self.assertIn("### MyStruct.Method (method) L20\n\n```go\n"
+                      "func (s *MyStruct) Method() {\n\tfmt.Println(\"Method\")\n}\n```\n", text)
+        # 默认使用 Markdown
+        self.assertEqual(render(self.result), text)
+
+        src = "package p\n\n// Answer 返回答案。\nfunc Answer() int { return 42 }\n"
+        text = render(GoAnalyzer().analyze_source("p.go", src))
+        self.assertIn("### Answer (function) L4\n\nAnswer 返回答案。\n\n```go\nfunc Answer() int { return 42 }\n```\n", text)
+
+        # 没有保留源码时退回签名
+        restored = FileAnalysis.from_json(self.result.to_json())
+        self.assertIn("```go\nfunc Function(a int) int\n```", render(restored))
+
+    def test_signatures(self):
+        lines = render(self.result, SignatureRenderer()).splitlines()
+        self.assertEqual(lines, [
+            f"// {self.result.path}",
+            "const ConstVal = 10",
+            'var GlobalVar = "hello"',
+            "type MyInterface interface",
+            "type MyStruct struct",
+            "type Alias",
+            "type StringAlias",
+            "type FuncType",
+            "func (s *MyStruct) Method()",
+            "func Function(a int) int",
+            "func GenericFunc[T any](val T) T",
+        ])
+
+    def test_delimited(self):
+        function = self.result.lookup("Function")
+        path = self.result.path
+        self.assertEqual(
+            DelimitedRenderer().wrap(self.result, function),
+            f'<file path="{path}" symbol="Function" lines="24-26">\nfunc Function(a int) int {{\n\treturn a\n}}\n</file>\n',
+        )
+        text = render(self.result, DelimitedRenderer())
+        self.assertEqual(text.count("</file>\n"), len(self.result.symbols))
+        self.assertIn('symbol="MyStruct.Method" lines="20-22">', text)
+
+        self.assertEqual(
+            DelimitedRenderer("markdown").wrap(self.result, function),
+            f"### {path}: Function (lines 24-26)\n\n```go\nfunc Function(a int) int {{\n\treturn a\n}}\n```\n",
+        )
+        self.assertEqual(
+            DelimitedRenderer("--- {symbol} {start}:{end} ---\n{body}").wrap(self.result, function),
+            "--- Function 24:26 ---\nfunc Function(a int) int {\n\treturn a\n}\n",
+        )
+
+        # 文档注释放在正文或头部
+        src = '// Answer returns "the" answer.\n// Always 42.\nfunc Answer() int { return 42 }\n'
+        analysis = GoAnalyzer().analyze_source("p.go", "package p\n\n" + src)
+        answer = analysis.symbols[0]
+        self.assertEqual(
+            DelimitedRenderer().wrap(analysis, answer),
+            '<file path="p.go" symbol="Answer" lines="5-5">\n' + src + "</file>\n",
+        )
+        self.assertEqual(
+            DelimitedRenderer(doc_in_header=True).wrap(analysis, answer),
+            '<file path="p.go" symbol="Answer" lines="5-5" doc="Answer returns &quot;the&quot; answer.&#10;Always 42.">\n'
+            "func Answer() int { return 42 }\n</file>\n",
+        )
+
+    def test_json(self):
+        text = render(self.result, JSONRenderer(indent=None))
+        self.assertEqual(FileAnalysis.from_dict(json.loads(text)), self.result)
+
+    def test_custom_renderer(self):
+        fp = io.StringIO()
+        text = render(self.result, NamesRenderer(), fp)
+        self.assertEqual(fp.getvalue(), text)
+        self.assertTrue(text.startswith("ConstVal,GlobalVar,"))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,44 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.globs import match
+
+
+class TestGlobs(unittest.TestCase):
+    def test_single_segment(self):
+        self.assertTrue(match("*.go", "a.go"))
+        self.assertFalse(match("*.go", "x/a.go"))
+        self.assertTrue(match("a?.go", "ab.go"))
+        self.assertTrue(match("[!x]*.go", "a.go"))
+        self.assertFalse(match("[!x]*.go", "x.go"))
+
+    def test_double_star(self):
+        self.assertTrue(match("**/*.pb.go", "a.pb.go"))
+        self.assertTrue(match("**/*.pb.go", "api/v1/a.pb.go"))
+        self.assertFalse(match("**/*.pb.go", "api/a.go"))
+        self.assertTrue(match("api/**", "api/v1/a.go"))
+        self.assertFalse(match("api/**", "apis/a.go"))
+        self.assertTrue(match("a/**/b.go", "a/b.go"))
+        self.assertTrue(match("a/**/b.go", "a/x/y/b.go"))
+
+    def test_literal_bracket_in_class(self):
+        self.assertTrue(match("[]a]", "]"))
+        self.assertTrue(match("[]a]", "a"))
+        self.assertFalse(match("[]a]", "b"))
+        self.assertTrue(match("[!]]x", "ax"))
+        self.assertFalse(match("[!]]x", "]x"))
+        self.assertTrue(match("[\\]]", "]"))
+
+    def test_invalid_pattern(self):
+        for pattern in ("[abc", "[]", "[!]", "[z-a]"):
+            with self.subTest(pattern=pattern):
+                with self.assertRaises(ValueError):
+                    match(pattern, "a")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,1369 @@
+import json
+import os
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+from dataclasses import asdict, replace
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.core import FileAnalysis, expand_deps, Kind, SCHEMA_VERSION, SortKey, StopWalk, TYPE_KINDS, group_by_package, group_by_type, package_stats, sort_symbols, sorted_symbols, unreferenced
+from analyzer.diff import body_hash
+from analyzer.parsers.go import GoAnalyzer, cyclomatic_complexity
+
+
+class TestGoAnalyzer(unittest.TestCase):
+    def setUp(self):
+        self.project_root = Path(__file__).parent.parent
+        self.codes_dir = self.project_root / "tests/codes"
+        if not self.codes_dir.exists():
+            self.skipTest("Fixtures directory not found at {}".format(self.codes_dir))
+        self.analyzer = GoAnalyzer()
+
+    def test_doc_comments(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo_doc.go")
+        docs = {s.name: s.docstring for s in result.symbols}
+
+        # 行注释：去掉 // 与前导空格
+        self.assertEqual(docs["ConstVal"], "ConstVal 是一个常量。")
+
+        # 空行分隔的游离注释不属于文档
+        self.assertEqual(docs["GlobalVar"], "")
+
+        # 多行块注释
+        self.assertEqual(docs["MyInterface"], "MyInterface 定义了一个方法。\n支持多行块注释。")
+
+        # 保留换行与段落空行
+        self.assertEqual(docs["MyStruct"], "MyStruct 是一个结构体。\n\n第二段说明。")
+
+        # 上一行是带行尾注释的代码，不是注释组
+        self.assertEqual(docs["Alias"], "")
+        self.assertEqual(docs["StringAlias"], "")
+
+        # 单行块注释
+        self.assertEqual(docs["Function"], "Function 原样返回参数。")
+
+    def test_json_round_trip(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        text = result.to_json()
+        data = json.loads(text)
+
+        # 顶层版本号与稳定的 snake_case 键名
+        self.assertEqual(data["schema_version"], SCHEMA_VERSION)
+        self.assertEqual(data["language"], "Go")
+        method = next(s for s in data["symbols"] if s["name"] == "Method")
+        self.assertEqual(
+            set(method),
+            {"name", "kind", "receiver", "start_line", "end_line", "start_col",
+             "end_col", "parameters", "decorators", "doc", "fields", "methods",
+             "embeds", "is_alias", "type_params", "signature", "references", "calls", "is_test", "test_kind", "value", "value_expr", "value_type",
+             "fingerprint", "complexity", "is_deprecated", "deprecation_note",
+             "source_offset", "source_end", "file"},
+        )
+        self.assertEqual(method["receiver"], "*MyStruct")
+        self.assertEqual(method["file"], result.path)
+
+        kinds = {s["name"]: s["kind"] for s in data["symbols"]}
+        self.assertEqual(kinds["ConstVal"], "const")
+        self.assertEqual(kinds["GlobalVar"], "variable")
+
+        # 反序列化后字段完全一致
+        self.assertEqual(FileAnalysis.from_json(text), result)
+
+    def test_positions(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        pos = {s.name: (s.line, s.column, s.end_line, s.end_column) for s in result.symbols}
+
+        # 单行常量覆盖整行：const ConstVal = 10
+        self.assertEqual(pos["ConstVal"], (5, 1, 5, 20))
+        # 多行结构体结束于右花括号之后
+        self.assertEqual(pos["MyStruct"], (12, 1, 14, 2))
+        # 方法体同样覆盖到右花括号
+        self.assertEqual(pos["Method"], (20, 1, 22, 2))
+        self.assertEqual(pos["GenericFunc"], (28, 1, 30, 2))
+
+    def test_analyze_source_matches_file(self):
+        path = self.codes_dir / "demo.go"
+        from_file = self.analyzer.analyze(path)
+        from_bytes = self.analyzer.analyze_source(str(path), path.read_bytes())
+        self.assertEqual(from_bytes, from_file)
+
+        # filename 只用于结果中的路径
+        in_memory = self.analyzer.analyze_source("remote/demo.go", path.read_bytes())
+        self.assertEqual(in_memory.path, "remote/demo.go")
+        self.assertEqual(in_memory.symbols, from_file.symbols)
+
+    def test_analyze_dir(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            for rel in ["main.go", "pkg/util.go", "vendor/dep.go", "testdata/fixture.go", ".git/hook.go"]:
+                (root / rel).parent.mkdir(parents=True, exist_ok=True)
+                (root / rel).write_text("package x\n\nfunc F() {}\n")
+            (root / "pkg/notes.txt").write_text("not go")
+            # 指向上级目录的符号链接形成环；失效链接会产生读取错误
+            os.symlink("..", root / "pkg/loop")
+            os.symlink("missing.go", root / "broken.go")
+
+            results, errors = self.analyzer.analyze_dir(root)
+            self.assertEqual(sorted(results), ["main.go", "pkg/util.go"])
+            self.assertEqual(results["pkg/util.go"].path, "pkg/util.go")
+            self.assertEqual([s.name for s in results["main.go"].symbols], ["F"])
+            self.assertEqual([e.path for e in errors], ["broken.go"])
+
+            # 覆盖默认跳过列表
+            results, _ = self.analyzer.analyze_dir(root, skip_dirs=["pkg"])
+            self.assertEqual(sorted(results), ["main.go", "testdata/fixture.go", "vendor/dep.go"])
+
+    def test_analyze_dir_workers(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            for i in range(12):
+                (root / f"p{i % 3}").mkdir(exist_ok=True)
+                (root / f"p{i % 3}/f{i}.go").write_text(f"package p\n\n// F{i} 文档。\nfunc F{i}() {{}}\n")
+            os.symlink("missing.go", root / "p2/broken.go")
+            os.symlink("missing.go", root / "p0/broken.go")
+
+            serial = self.analyzer.analyze_dir(root, workers=1)
+            parallel = self.analyzer.analyze_dir(root, workers=4)
+            self.assertEqual(parallel, serial)
+            self.assertEqual(list(parallel[0]), list(serial[0]))
+            self.assertEqual([e.path for e in parallel[1]], ["p0/broken.go", "p2/broken.go"])
+            # 跨进程返回的结果仍保留源码
+            self.assertEqual(parallel[0]["p1/f1.go"].symbols[0].source(), "// F1 文档。\nfunc F1() {}")
+
+            with self.assertRaises(ValueError):
+                self.analyzer.analyze_dir(root, workers=0)
+
+    def test_analyze_dir_stream(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "a.go").write_text("package a\n\nimport \"fmt\"\n\nfunc A1() {}\n\nfunc A2() {}\n")
+            (root / "b").mkdir()
+            (root / "b/b.go").write_text("package b\n\nfunc B1() {}\n")
+            os.symlink("missing.go", root / "broken.go")
+
+            events = []
+            errors = self.analyzer.analyze_dir_stream(
+                root,
+                on_symbol=lambda s: events.append(("symbol", s.file, s.name)),
+                on_file=lambda f: events.append(("file", f.path, f.package, f.imports)),
+            )
+            # 文件级信息先于该文件的符号
+            self.assertEqual(events, [
+                ("file", "a.go", "a", ["fmt"]),
+                ("symbol", "a.go", "A1"),
+                ("symbol", "a.go", "A2"),
+                ("file", "b/b.go", "b", []),
+                ("symbol", "b/b.go", "B1"),
+            ])
+            self.assertEqual([e.path for e in errors], ["broken.go"])
+
+            def stop_after_two(sym):
+                seen.append(sym.name)
+                if len(seen) == 2:
+                    raise StopWalk
+
+            seen = []
+            self.analyzer.analyze_dir_stream(root, on_symbol=stop_after_two)
+            self.assertEqual(seen, ["A1", "A2"])
+
+            def fail(sym):
+                raise RuntimeError("database unavailable")
+
+            with self.assertRaises(RuntimeError):
+                self.analyzer.analyze_dir_stream(root, on_symbol=fail)
+
+    def test_analyzer_options(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "a.go").write_text("package a\n\nfunc Public() {}\n\nfunc private() {}\n")
+            (root / "a_test.go").write_text("package a\n\nfunc TestPublic(t *testing.T) {}\n")
+
+            # 不带选项时与之前的默认行为一致
+            self.assertEqual(GoAnalyzer().analyze_dir(root, workers=1),
+                             self.analyzer.analyze_dir(root, workers=1, include_tests=True))
+
+            configured = GoAnalyzer(exported_only=True, include_tests=False, workers=1)
+            results, _ = configured.analyze_dir(root)
+            self.assertEqual(list(results), ["a.go"])
+            self.assertEqual([s.name for s in results["a.go"].symbols], ["Public"])
+            # 调用时的参数优先于构造时的选项
+            results, _ = configured.analyze_dir(root, include_tests=True)
+            self.assertEqual(list(results), ["a.go", "a_test.go"])
+
+            with self.assertRaises(ValueError):
+                GoAnalyzer(workers=0)
+            with self.assertRaises(ValueError):
+                GoAnalyzer(cache_dir=root / "a.go")
+
+    def test_include_exclude(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "a.go").write_text("package a\n\nfunc A() {}\n")
+            (root / "root.pb.go").write_text("package a\n\nfunc Root() {}\n")
+            (root / "api").mkdir()
+            (root / "api/x.pb.go").write_text("package api\n\nfunc X() {}\n")
+            (root / "api/server.go").write_text("package api\n\nfunc Serve() {}\n")
+
+            results, _ = self.analyzer.analyze_dir(root, workers=1, exclude=["**/*.pb.go"])
+            self.assertEqual(list(results), ["a.go", "api/server.go"])
+
+            # exclude 优先于 include
+            results, _ = self.analyzer.analyze_dir(root, workers=1, include=["api/**"], exclude=["**/*.pb.go"])
+            self.assertEqual(list(results), ["api/server.go"])
+
+            # 构造时的选项同样作用于 analyze_dir_stream
+            names = []
+            GoAnalyzer(include=["*.go"]).analyze_dir_stream(root, on_symbol=lambda s: names.append(s.name))
+            self.assertEqual(names, ["A", "Root"])
+
+            with self.assertRaises(ValueError):
+                GoAnalyzer(exclude=["[abc"])
+
+    def test_stats(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        stats = result.stats()
+        self.assertEqual(stats.files, 1)
+        self.assertEqual(stats.lines, result.lines)
+        self.assertEqual(stats.counts["function"], 2)
+        self.assertEqual(stats.counts["method"], 1)
+        self.assertEqual(stats.counts["struct"], 1)
+        self.assertEqual(sum(stats.counts.values()), len(result.symbols))
+        self.assertEqual(stats.complexity["MyStruct.Method"], 1)
+        self.assertEqual(stats.complexity["Function"], 1)
+
+        src = b"""package p
+
+func Branchy(xs []int, ok bool) int {
+	n := 0
+	for _, x := range xs {
+		if x > 0 && ok || x < -10 {
+			n++
+		}
+	}
+	switch n {
+	case 0, 1:
+		return 0
+	default:
+		return n // if in a comment does not count
+	}
+}
+"""
+        other = self.analyzer.analyze_source("b.go", src)
+        # for + if + && + || + case
+        self.assertEqual(other.symbols[0].complexity, 6)
+        self.assertEqual(cyclomatic_complexity('{ s := "if for"; if s != "" {} }'), 2)
+
+        packages = package_stats([result, other, replace(other, path="c.go")])
+        self.assertEqual(list(packages), ["main", "p"])
+        self.assertEqual(packages["p"].files, 2)
+        self.assertEqual(packages["p"].counts, {"function": 2})
+        self.assertEqual(packages["p"].complexity, {"Branchy": 6})
+
+    def test_retain_tokens(self):
+        # 默认不保留
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertIsNone(result.scanner)
+        self.assertTrue(all(s.node() is None for s in result.symbols))
+
+        src = b"""package p
+
+// Greet says hello.
+func Greet(name string) string {
+	return "hi " + name
+}
+
+const (
+	A = iota
+	B
+)
+"""
+        result = GoAnalyzer(retain_tokens=True).analyze_source("p.go", src)
+        greet = result.lookup("Greet")
+        node = greet.node()
+        self.assertEqual(node[0].value, "func")
+        self.assertEqual(node[-1].value, "}")
+        self.assertNotIn("comment", {t.kind for t in node})
+        # 通过扫描器把偏移换算为行列
+        self.assertEqual(result.scanner.position(node[0].offset), (greet.line, greet.column))
+        # 分组声明中的符号共享同一序列
+        self.assertIs(result.lookup("A").node(), result.lookup("B").node())
+        # 保留 token 不影响其余输出
+        self.assertEqual(result, self.analyzer.analyze_source("p.go", src))
+        retained = GoAnalyzer(retain_tokens=True).analyze_source("p.go", src)
+        self.assertEqual(asdict(retained), asdict(self.analyzer.analyze_source("p.go", src)))
+        # 派生的副本保留 token
+        self.assertIs(result.filter_exported().lookup("Greet").node(), node)
+
+    def test_unreferenced(self):
+        src = b"""package p
+
+type runner interface{ run() }
+
+type task struct{}
+
+var _ runner = (*task)(nil)
+
+func (t *task) run() { t.step(); f := t.value; _ = f; helper() }
+func (t *task) step() {}
+func (t *task) value() int { return 0 }
+func (t *task) dead() {}
+
+func helper() {}
+func unused() {}
+func Exported() {}
+func init() {}
+func main() {}
+
+const limit = 3
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+        self.assertEqual([s.key for s in result.unreferenced()], ["task.dead", "unused", "limit"])
+
+        # 跨文件的引用需要合并同一包的全部符号
+        other = self.analyzer.analyze_source("q.go", b"package p\n\nfunc use() int { return limit }\n")
+        merged = result.merge(other)
+        self.assertEqual([s.key for s in unreferenced(merged.symbols)], ["task.dead", "unused", "use"])
+
+        # 测试函数不算未引用
+        tests = self.analyzer.analyze_source("p_test.go", b"package p\n\nfunc TestX(t *testing.T) {}\n")
+        self.assertEqual(tests.unreferenced(), [])
+
+        # 没有源码时以方法值形式的使用无法识别
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertEqual([s.key for s in restored.unreferenced()], ["task.value", "task.dead", "unused", "limit"])
+
+    def test_expand_deps(self):
+        src = b"""package p
+
+import "fmt"
+
+func A() { B(); fmt.Println() }
+func B() { C() }
+func C() {}
+func D() { A() }
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+
+        def names(seeds, depth):
+            return [s.name for s in result.expand_deps(seeds, depth)]
+
+        self.assertEqual(names(["A"], 0), ["A"])
+        self.assertEqual(names(["A"], 1), ["A", "B"])
+        self.assertEqual(names(["A"], 2), ["A", "B", "C"])
+        self.assertEqual(names(["A"], 10), ["A", "B", "C"])
+        # 种子之间共享的依赖只出现一次
+        self.assertEqual(names(["D", "B"], 1), ["D", "B", "A", "C"])
+        self.assertEqual([s.name for s in expand_deps(result.symbols, ["C", "C"], 1)], ["C"])
+
+        with self.assertRaises(ValueError):
+            result.expand_deps(["Missing"], 1)
+        with self.assertRaises(ValueError):
+            result.expand_deps(["A"], -1)
+
+    def test_sort_symbols(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        source_order = [s.name for s in result.symbols]
+
+        def names(by):
+            return [s.name for s in sorted_symbols(result.symbols, by)]
+
+        self.assertEqual(names(SortKey.POSITION), source_order)
+        self.assertEqual(sorted_symbols(reversed(result.symbols)), result.symbols)
+        self.assertEqual(names(SortKey.NAME), [
+            "Alias", "ConstVal", "Function", "FuncType", "GenericFunc",
+            "GlobalVar", "Method", "MyInterface", "MyStruct", "StringAlias",
+        ])
+        self.assertEqual(names(SortKey.KIND), [
+            "ConstVal", "GlobalVar", "Alias", "MyInterface", "MyStruct",
+            "Function", "GenericFunc", "Method", "StringAlias", "FuncType",
+        ])
+        # 行数相同的符号保持源码顺序
+        self.assertEqual(names(SortKey.SIZE), [
+            "MyInterface", "MyStruct", "Method", "Function", "GenericFunc",
+            "ConstVal", "GlobalVar", "Alias", "StringAlias", "FuncType",
+        ])
+        self.assertEqual(names("name"), names(SortKey.NAME))
+
+        # sort_symbols 原地排序，sorted_symbols 不修改输入
+        symbols = list(result.symbols)
+        sort_symbols(symbols, SortKey.NAME)
+        self.assertEqual([s.name for s in symbols], names(SortKey.NAME))
+        self.assertEqual([s.name for s in result.symbols], source_order)
+
+        with self.assertRaises(ValueError):
+            sort_symbols(symbols, "size_desc")
+
+    def test_filter_exported(self):
+        src = b"""package demo
+
+const ConstVal = 10
+const maxRetries = 3
+var GlobalVar = "hello"
+var _hidden = 0
+
+type MyStruct struct{}
+type helper struct{}
+
+func (s *MyStruct) Method() {}
+func (s *MyStruct) reset() {}
+func (h helper) Exposed() {}
+
+func Function() {}
+func internal() {}
+"""
+        result = self.analyzer.analyze_source("mixed.go", src).filter_exported()
+        names = [s.name for s in result.symbols]
+        self.assertEqual(
+            names,
+            ["ConstVal", "GlobalVar", "MyStruct", "Method", "Exposed", "Function"],
+        )
+
+    def test_struct_fields(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        my_struct = next(s for s in result.symbols if s.name == "MyStruct")
+        self.assertEqual(len(my_struct.fields), 1)
+        self.assertEqual((my_struct.fields[0].name, my_struct.fields[0].type), ("Field", "int"))
+        self.assertFalse(my_struct.fields[0].embedded)
+
+        src = """package demo
+
+type Reader struct {
+	io.Reader
+	*Base
+	List[int]
+	// ID 是主键。
+	ID      int64             `json:"id" db:"id"`
+	X, Y    float64           // 坐标
+	Items   []*Item           `json:"items,omitempty"`
+	Lookup  map[string][]int
+	OnEvent func(name string) error
+	inner   struct{ A int }
+}
+"""
+        reader = self.analyzer.analyze_source("fields.go", src).symbols[0]
+        fields = [(f.name, f.type, f.tag, f.embedded) for f in reader.fields]
+        self.assertEqual(fields, [
+            ("", "io.Reader", "", True),
+            ("", "*Base", "", True),
+            ("", "List[int]", "", True),
+            ("ID", "int64", 'json:"id" db:"id"', False),
+            ("X", "float64", "", False),
+            ("Y", "float64", "", False),
+            ("Items", "[]*Item", 'json:"items,omitempty"', False),
+            ("Lookup", "map[string][]int", "", False),
+            ("OnEvent", "func(name string) error", "", False),
+            ("inner", "struct{ A int }", "", False),
+        ])
+        self.assertEqual(reader.fields[3].docstring, "ID 是主键。")
+        self.assertEqual(reader.fields[4].comment, "坐标")
+
+        # 导出过滤可以同时去掉未导出的字段
+        exported = self.analyzer.analyze_source("fields.go", src).filter_exported()
+        self.assertNotIn("inner", [f.name for f in exported.symbols[0].fields])
+        self.assertEqual(len(exported.symbols[0].fields), 9)
+
+    def test_interface_methods(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        iface = next(s for s in result.symbols if s.name == "MyInterface")
+        self.assertEqual([(m.name, m.parameters, m.results) for m in iface.methods], [("Method", [], [])])
+
+        src = b"""package demo
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	io.Reader
+	Closer
+	// Read reads up to len(p) bytes.
+	Read(p []byte) (n int, err error)
+	Seek(offset int64, whence int) (int64, error)
+	Printf(format string, args ...any)
+	Pair(a, b int) bool
+}
+"""
+        # 默认：嵌入接口按名称引用
+        rc = self.analyzer.analyze_source("iface.go", src).symbols[1]
+        self.assertEqual(rc.embeds, ["io.Reader", "Closer"])
+        self.assertEqual(
+            [(m.name, m.parameters, m.results) for m in rc.methods],
+            [
+                ("Read", ["p []byte"], ["n int", "err error"]),
+                ("Seek", ["offset int64", "whence int"], ["int64", "error"]),
+                ("Printf", ["format string", "args ...any"], []),
+                ("Pair", ["a int", "b int"], ["bool"]),
+            ],
+        )
+        self.assertEqual(rc.methods[0].docstring, "Read reads up to len(p) bytes.")
+
+        # 展开：同一文件内的 Closer 被展开，外部的 io.Reader 保留引用
+        rc = GoAnalyzer(expand_embedded=True).analyze_source("iface.go", src).symbols[1]
+        self.assertEqual(rc.embeds, ["io.Reader"])
+        self.assertEqual([m.name for m in rc.methods], ["Read", "Seek", "Printf", "Pair", "Close"])
+
+    def test_type_alias(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        aliases = {s.name: s.is_alias for s in result.symbols if s.type in TYPE_KINDS}
+        self.assertFalse(aliases["Alias"])
+        self.assertTrue(aliases["StringAlias"])
+        self.assertFalse(aliases["FuncType"])
+        self.assertFalse(aliases["MyStruct"])
+
+        src = b"""package demo
+
+type (
+	Set[T comparable] = map[T]struct{}
+	Grid [3][3]int
+)
+"""
+        result = self.analyzer.analyze_source("alias.go", src)
+        self.assertEqual([(s.name, s.is_alias) for s in result.symbols], [("Set", True), ("Grid", False)])
+
+    def test_type_params(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        generic = next(s for s in result.symbols if s.name == "GenericFunc")
+        self.assertEqual([(t.name, t.constraint) for t in generic.type_params], [("T", "any")])
+        function = next(s for s in result.symbols if s.name == "Function")
+        self.assertEqual(function.type_params, [])
+
+        src = b"""package demo
+
+func F[K comparable, V any](m map[K]V) {}
+
+func Keys[M ~map[K]V, K, V2 comparable](m M) []K { return nil }
+
+type Number[T ~int | ~float64] struct{ v T }
+
+type List[T any] interface {
+	Get(i int) T
+}
+
+type Matrix [4]int
+"""
+        params = {
+            s.name: [(t.name, t.constraint) for t in s.type_params]
+            for s in self.analyzer.analyze_source("generic.go", src).symbols
+        }
+        self.assertEqual(params["F"], [("K", "comparable"), ("V", "any")])
+        self.assertEqual(params["Keys"], [("M", "~map[K]V"), ("K", "comparable"), ("V2", "comparable")])
+        self.assertEqual(params["Number"], [("T", "~int | ~float64")])
+        self.assertEqual(params["List"], [("T", "any")])
+        self.assertEqual(params["Matrix"], [])
+
+    def test_signatures(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        sigs = {s.name: s.signature for s in result.symbols if s.type in (Kind.FUNC, Kind.METHOD)}
+        self.assertEqual(sigs["Method"], "func (s *MyStruct) Method()")
+        self.assertEqual(sigs["Function"], "func Function(a int) int")
+        self.assertEqual(sigs["GenericFunc"], "func GenericFunc[T any](val T) T")
+
+        src = b"""package demo
+
+func Sum(base int, nums ...int) int { return 0 }
+
+func Divide(a, b float64) (quotient float64, err error) { return }
+
+func Handle(int, string) error { return nil }
+
+func (List[T]) Len() int { return 0 }
+
+func Multi(
+	ctx context.Context,
+	opts map[string]any,
+) (chan<- struct{}, func() error) {
+	return nil, nil
+}
+
+func Empty() interface{} { return nil }
+"""
+        result = self.analyzer.analyze_source("sigs.go", src)
+        sigs = {s.name: s.signature for s in result.symbols}
+        self.assertEqual(sigs["Sum"], "func Sum(base int, nums ...int) int")
+        self.assertEqual(sigs["Divide"], "func Divide(a, b float64) (quotient float64, err error)")
+        self.assertEqual(sigs["Handle"], "func Handle(int, string) error")
+        self.assertEqual(sigs["Len"], "func (List[T]) Len() int")
+        self.assertEqual(
+            sigs["Multi"],
+            "func Multi(ctx context.Context, opts map[string]any) (chan<- struct{}, func() error)",
+        )
+        self.assertEqual(sigs["Empty"], "func Empty() interface{}")
+
+        params = {s.name: s.parameters for s in result.symbols}
+        self.assertEqual(params["Divide"], ["a float64", "b float64"])
+        self.assertEqual(params["Handle"], ["int", "string"])
+
+        # 名称与切片/数组类型之间有空格，泛型实例化保持紧凑，数组长度按表达式排版
+        src = b"""package demo
+
+func (b *Buffer) Write(p []byte) (n int, err error) { return }
+
+func Fill(b [16]int, out *[2 * N]byte, grid [][3]int, items List[T]) {}
+
+func ReadLine() (line []byte, err error) { return }
+
+type Block struct {
+	Data [BlockSize]byte
+	Next *List[T]
+}
+"""
+        result = self.analyzer.analyze_source("arrays.go", src)
+        sigs = {s.name: s.signature for s in result.symbols}
+        self.assertEqual(sigs["Write"], "func (b *Buffer) Write(p []byte) (n int, err error)")
+        self.assertEqual(sigs["Fill"], "func Fill(b [16]int, out *[2 * N]byte, grid [][3]int, items List[T])")
+        self.assertEqual(sigs["ReadLine"], "func ReadLine() (line []byte, err error)")
+        self.assertEqual([f.type for f in result.lookup("Block").fields], ["[BlockSize]byte", "*List[T]"])
+
+    def test_group_by_type(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        groups, orphans = result.group_by_type()
+        self.assertEqual([m.name for m in groups["MyStruct"].methods], ["Method"])
+        self.assertEqual(groups["Alias"].methods, [])
+        self.assertEqual(orphans, [])
+
+        src = b"""package demo
+
+type Stack[T any] struct{ items []T }
+
+func (s *Stack[T]) Push(v T) {}
+func (s Stack[T]) Len() int { return 0 }
+func (c *Conn) Close() error { return nil }
+"""
+        result = self.analyzer.analyze_source("stack.go", src)
+        groups, orphans = result.group_by_type()
+        self.assertEqual([m.name for m in groups["Stack"].methods], ["Push", "Len"])
+        self.assertEqual([m.name for m in orphans], ["Close"])
+
+        # 跨文件：Conn 在另一个文件中定义
+        other = self.analyzer.analyze_source("conn.go", b"package demo\n\ntype Conn struct{}\n")
+        groups, orphans = group_by_type(result.symbols + other.symbols)
+        self.assertEqual([m.name for m in groups["Conn"].methods], ["Close"])
+        self.assertEqual(orphans, [])
+
+    def test_imports(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertEqual(len(result.import_specs), 1)
+        spec = result.import_specs[0]
+        self.assertEqual((spec.path, spec.alias, spec.is_dot, spec.is_blank), ("fmt", "", False, False))
+
+        src = b"""package p
+
+import "os"
+
+import (
+\t"fmt"
+\tstrs "strings"
+
+\t. "math"
+\t_ "embed"
+\t// \xe6\xb3\xa8\xe9\x87\x8a
+\t"os"
+)
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+        specs = [(i.path, i.alias, i.line, i.group) for i in result.import_specs]
+        self.assertEqual(specs, [
+            ("os", "", 3, 0),
+            ("fmt", "", 6, 1),
+            ("strings", "strs", 7, 1),
+            ("math", ".", 9, 2),
+            ("embed", "_", 10, 2),
+            ("os", "", 12, 2),
+        ])
+        self.assertTrue(result.import_specs[3].is_dot)
+        self.assertTrue(result.import_specs[4].is_blank)
+        # imports 保持源码顺序并去重
+        self.assertEqual(result.imports, ["os", "fmt", "strings", "math", "embed"])
+
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertEqual(restored.import_specs, result.import_specs)
+        self.assertEqual(json.loads(result.to_json())["import_specs"][3]["dot"], True)
+
+    def test_dependencies(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        graph = result.dependencies()
+        self.assertEqual(set(graph), {s.key for s in result.symbols})
+        self.assertEqual(graph["MyStruct.Method"].internal, ["MyStruct"])
+        self.assertEqual(graph["MyStruct.Method"].external, ["fmt"])
+        self.assertEqual(graph["Function"].internal, [])
+
+        src = b"""package p
+
+import (
+\tstr "strings"
+\t"gopkg.in/yaml.v3"
+\t"example.com/mod/v2"
+)
+
+type Config struct {
+\tName string
+\tOpts Options
+}
+
+type Options map[string]int
+
+func New(name string) *Config {
+\treturn &Config{Name: str.TrimSpace(name), Opts: Options{}}
+}
+
+func Load(data []byte) (*Config, error) {
+\tc := New("")
+\treturn c, yaml.Unmarshal(data, c)
+}
+
+func Version() string { return mod.Version }
+"""
+        graph = self.analyzer.analyze_source("p.go", src).dependencies()
+        self.assertEqual(graph["Config"].internal, ["Options"])
+        self.assertEqual(graph["New"].internal, ["Config", "Options"])
+        self.assertEqual(graph["New"].external, ["strings"])
+        self.assertEqual(graph["Load"].internal, ["Config", "New"])
+        self.assertEqual(graph["Load"].external, ["gopkg.in/yaml.v3"])
+        self.assertEqual(graph["Version"].external, ["example.com/mod/v2"])
+        self.assertEqual(graph["Options"].internal, [])
+
+    def test_source(self):
+        src = """package p
+
+import "fmt"
+
+// Config 保存配置。
+//
+// 包含注释与尾逗号。
+type Config struct {
+	Name  string // 名称
+	Ports []int  `json:"ports"`
+}
+
+var (
+	// Default 是默认配置。
+	Default = Config{
+		Name:  "x",
+		Ports: []int{80, 443,},
+	}
+	other int
+)
+
+func (c *Config) String() string {
+	/* 保留体内注释 */
+	return fmt.Sprint(c.Name,
+		c.Ports,
+	)
+}
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+        by_name = {s.name: s for s in result.symbols}
+        self.assertEqual(by_name["Config"].source(), src[src.index("// Config"):src.index("}\n\nvar") + 1])
+        # 分组中的 spec 从注释处开始，保留原有缩进与尾逗号
+        self.assertEqual(by_name["Default"].source(),
+                         '// Default 是默认配置。\n\tDefault = Config{\n\t\tName:  "x",\n\t\tPorts: []int{80, 443,},\n\t}')
+        self.assertEqual(by_name["other"].source(), "other int")
+        method = by_name["String"].source()
+        self.assertTrue(method.startswith("func (c *Config) String() string {\n\t/* 保留体内注释 */"))
+        self.assertTrue(method.endswith("\t\tc.Ports,\n\t)\n}"))
+        self.assertEqual(by_name["String"].source_bytes(), method.encode("utf-8"))
+
+        # 从 JSON 还原的结果不带源码；drop_source 释放源码
+        self.assertEqual(FileAnalysis.from_json(result.to_json()).symbols[0].source(), "")
+        result.drop_source()
+        self.assertEqual(by_name["Config"].source(), "")
+
+    def test_const_values(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        const = next(s for s in result.symbols if s.name == "ConstVal")
+        self.assertEqual((const.value, const.value_expr), ("10", "10"))
+
+        src = b"""package p
+
+const (
+\tA = iota
+\tB
+\tC
+)
+
+type Size int64
+
+const (
+\t_ = iota
+\tKB Size = 1 << (10 * iota)
+\tMB
+\tGB
+)
+
+const (
+\tRead, Write = 1 << iota, 2 << iota
+\tExec, Sticky
+)
+
+const (
+\tGreeting = "hello, " + "world"
+\tPi       = 3.5
+\tHex      = 0x1F &^ 0x3
+\tOctal    = 0o17 + 017
+\tNeg      = -7 / 2
+\tRune     = 'a' + 1
+\tTotal    = GB / MB
+\tMax      = len("abc")
+\tTyped    = Size(2)
+\tExtern   = math.MaxInt8
+)
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+        consts = {s.name: (s.value, s.value_expr) for s in result.symbols if s.type == "const"}
+        self.assertEqual(consts["A"], ("0", "iota"))
+        self.assertEqual(consts["B"], ("1", "iota"))
+        self.assertEqual(consts["C"], ("2", "iota"))
+        self.assertEqual(consts["KB"], ("1024", "1 << (10 * iota)"))
+        self.assertEqual(consts["MB"][0], "1048576")
+        self.assertEqual(consts["GB"][0], "1073741824")
+        self.assertEqual(consts["Read"], ("1", "1 << iota"))
+        self.assertEqual(consts["Exec"][0], "2")
+        self.assertEqual(consts["Greeting"], ('"hello, world"', '"hello, " + "world"'))
+        self.assertEqual(consts["Pi"][0], "3.5")
+        self.assertEqual(consts["Hex"][0], "28")
+        self.assertEqual(consts["Octal"][0], "30")
+        self.assertEqual(consts["Neg"][0], "-3")
+        self.assertEqual(consts["Rune"][0], "98")
+        self.assertEqual(consts["Total"][0], "1024")
+        # 函数调用、类型转换与外部常量无法静态求值，但保留表达式
+        self.assertEqual(consts["Max"], ("", 'len("abc")'))
+        self.assertEqual(consts["Typed"], ("", "Size(2)"))
+        self.assertEqual(consts["Extern"], ("", "math.MaxInt8"))
+
+    def test_filter_by_kind(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertEqual([s.name for s in result.filter_by_kind(Kind.FUNC)], ["Function", "GenericFunc"])
+        self.assertEqual([s.name for s in result.filter_by_kind(Kind.METHOD)], ["Method"])
+        self.assertEqual([s.name for s in result.filter_by_kind(Kind.STRUCT, Kind.INTERFACE)], ["MyInterface", "MyStruct"])
+        kinds = {s.name: s.kind for s in result.symbols}
+        self.assertEqual(kinds["Alias"], Kind.TYPE)
+        self.assertEqual(kinds["StringAlias"], Kind.ALIAS)
+        self.assertEqual(kinds["FuncType"], Kind.FUNC_TYPE)
+        self.assertEqual(kinds["GlobalVar"], Kind.VAR)
+
+        # 字符串形式可读，且与 JSON 中的 kind 一致；还原后仍为 Kind
+        self.assertEqual(str(Kind.FUNC_TYPE), "func_type")
+        self.assertEqual(f"{Kind.METHOD}", "method")
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertIs(restored.symbols[-1].kind, Kind.FUNC)
+        self.assertEqual(json.loads(result.to_json())["symbols"][-3]["kind"], "method")
+
+    def test_test_files(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertFalse(result.is_test)
+        self.assertFalse(any(s.is_test for s in result.symbols))
+
+        src = b"""package demo
+
+import "testing"
+
+func TestFunction(t *testing.T) {}
+func Test_edge(t *testing.T) {}
+func Testify() {}
+func BenchmarkFunction(b *testing.B) {}
+func ExampleFunction() {}
+func Example() {}
+func FuzzParse(f *testing.F) {}
+func helper() {}
+"""
+        result = self.analyzer.analyze_source("demo_test.go", src)
+        self.assertTrue(result.is_test)
+        self.assertTrue(all(s.is_test for s in result.symbols))
+        kinds = {s.name: s.test_kind for s in result.symbols}
+        self.assertEqual(kinds, {
+            "TestFunction": "test", "Test_edge": "test", "Testify": "",
+            "BenchmarkFunction": "benchmark", "ExampleFunction": "example", "Example": "example",
+            "FuzzParse": "fuzz", "helper": "",
+        })
+        self.assertEqual(result.filter_production().symbols, [])
+
+        # 包名以 _test 结尾也视为测试文件；非测试文件中的 TestXxx 不是测试函数
+        self.assertTrue(self.analyzer.analyze_source("x.go", b"package demo_test\n").is_test)
+        plain = self.analyzer.analyze_source("x.go", b"package demo\n\nfunc TestMain() {}\n")
+        self.assertEqual(plain.symbols[0].test_kind, "")
+
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "a.go").write_text("package x\n\nfunc A() {}\n")
+            (root / "a_test.go").write_text("package x\n\nfunc TestA() {}\n")
+            (root / "ext.go").write_text("package x_test\n\nfunc B() {}\n")
+            results, _ = self.analyzer.analyze_dir(root, workers=1)
+            self.assertEqual(sorted(results), ["a.go", "a_test.go", "ext.go"])
+            results, _ = self.analyzer.analyze_dir(root, workers=1, include_tests=False)
+            self.assertEqual(sorted(results), ["a.go"])
+
+    def test_outline(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertEqual(result.outline(), "\n".join([
+            "consts: ConstVal (L5)",
+            "vars: GlobalVar (L6)",
+            "types:",
+            "  MyInterface (L8)",
+            "  MyStruct (L12)",
+            "    Method (L20)",
+            "  Alias (L16)",
+            "  StringAlias (L17)",
+            "  FuncType (L18)",
+            "funcs: Function (L24), GenericFunc (L28)",
+        ]))
+
+        src = """package p
+
+// Close 关闭连接。
+func (c *Conn) Close() error { return nil }
+func Dial() {}
+"""
+        self.assertEqual(self.analyzer.analyze_source("p.go", src).outline(),
+                         "funcs: Dial (L5)\nmethods: Conn.Close (L4)")
+
+    def test_lookup(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        function = result.lookup("Function")
+        self.assertIsNotNone(function)
+        self.assertEqual((function.name, function.kind), ("Function", Kind.FUNC))
+        method = result.lookup("MyStruct.Method")
+        self.assertEqual((method.name, method.receiver), ("Method", "*MyStruct"))
+        self.assertIs(result.lookup("(*MyStruct).Method"), method)
+        # 方法只能用限定名查找
+        self.assertIsNone(result.lookup("Method"))
+        self.assertIsNone(result.lookup("Missing"))
+        self.assertIsNone(result.lookup("MyStruct.Missing"))
+
+        # 过滤后的副本使用自己的索引
+        exported = result.filter_exported()
+        self.assertIs(exported.lookup("Function"), next(s for s in exported.symbols if s.name == "Function"))
+        self.assertEqual(FileAnalysis.from_json(result.to_json()), result)
+        # 索引不是字段，不会被 asdict 复制
+        self.assertNotIn("_by_key", asdict(result))
+
+    def test_calls(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        calls = {s.key: s.calls for s in result.symbols}
+        self.assertEqual(calls["MyStruct.Method"], ["fmt.Println"])
+        self.assertEqual(calls["Function"], [])
+        self.assertEqual(calls["ConstVal"], [])
+
+        src = b"""package p
+
+func (s *Server) Run(items []Item) error {
+\tdefer s.mu.Unlock()
+\ts.mu.Lock()
+\tfor i := range items {
+\t\tif err := validate(items[i]); err != nil {
+\t\t\treturn fmt.Errorf("item %d: %w", i, err)
+\t\t}
+\t\ts.handlers[i].Handle(string(items[i].Name), len(items))
+\t}
+\tgo func() { s.notify() }()
+\tb := []byte("x")
+\t_ = Map[int](b)
+\tvalidate(items[0])
+\treturn s.store().Save()
+}
+"""
+        run = self.analyzer.analyze_source("p.go", src).symbols[0]
+        self.assertEqual(run.calls, [
+            "s.mu.Unlock", "s.mu.Lock", "validate", "fmt.Errorf", "s.handlers[i].Handle", "len",
+            "s.notify", "Map[int]", "s.store", "s.store().Save",
+        ])
+
+    def test_partial_parse(self):
+        src = (
+            "package demo\n"
+            "\n"
+            "func Function() int {\n"
+            "\treturn 1\n"
+            "}\n"
+            "\n"
+            "type MyStruct struct {\n"
+            "\tField int\n"
+            "\n"
+            "func After() {}\n"
+            "\n"
+            "x := 1\n"
+            "\n"
+            "var s = \"abc\n"
+        )
+        result = self.analyzer.analyze_source("broken.go", src)
+
+        # 括号未闭合的 MyStruct 被丢弃，之前和之后的声明仍然可用
+        self.assertTrue(result.partial)
+        self.assertEqual([s.name for s in result.symbols], ["Function", "After", "s"])
+        self.assertEqual(
+            [(e.positions[0].line, e.positions[0].column, e.message) for e in result.errors],
+            [(10, 1, "expected '}', found 'func'"),
+             (12, 1, "expected declaration, found 'x'"),
+             (14, 9, "string literal not terminated")],
+        )
+        self.assertEqual({e.code for e in result.errors}, {"syntax"})
+        self.assertEqual(str(result.errors[0]), "broken.go:10:1: error: expected '}', found 'func'")
+
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertTrue(restored.partial)
+        self.assertEqual(restored.errors, result.errors)
+
+        clean = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertFalse(clean.partial)
+        self.assertEqual(clean.errors, [])
+
+        missing = self.analyzer.analyze_source("missing.go", "func F() {}\n")
+        self.assertTrue(missing.partial)
+        self.assertEqual(missing.errors[0].message, "expected 'package', found 'func'")
+        self.assertEqual([s.name for s in missing.symbols], ["F"])
+
+    def test_value_types(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        by_name = {s.name: s for s in result.symbols}
+        self.assertEqual(by_name["GlobalVar"].value_type, "string")
+        self.assertEqual(by_name["ConstVal"].value_type, "int")
+
+        src = (
+            "package p\n"
+            "\n"
+            "var (\n"
+            "\tTimeout time.Duration = 5\n"
+            "\tRatio = 1.5\n"
+            "\tEnabled = !false\n"
+            "\tLetter = 'a' + 1\n"
+            "\tNames = []string{\"a\"}\n"
+            "\tDefault = &Config{Name: \"x\"}\n"
+            "\tHandler = func(w io.Writer) error { return nil }\n"
+            "\tSmall = uint8(3)\n"
+            "\tClient = http.NewClient()\n"
+            "\tZero int\n"
+            ")\n"
+            "\n"
+            "const (\n"
+            "\tA Weekday = iota\n"
+            "\tB\n"
+            "\tKB = 1 << 10\n"
+            ")\n"
+        )
+        by_name = {s.name: s for s in self.analyzer.analyze_source("p.go", src).symbols}
+        self.assertEqual(
+            {name: s.value_type for name, s in by_name.items()},
+            {"Timeout": "time.Duration", "Ratio": "float64", "Enabled": "bool", "Letter": "rune",
+             "Names": "[]string", "Default": "*Config", "Handler": "func(w io.Writer) error",
+             "Small": "uint8", "Client": "", "Zero": "int", "A": "Weekday", "B": "Weekday", "KB": "int"},
+        )
+        # 推断出的字面量类型中，切片/数组字段与名称之间有空格
+        src = (
+            "package p\n"
+            "\n"
+            "var Split = func() (ss, ct []byte) { return nil, nil }\n"
+            "var Header = struct {\n"
+            "\tMagic [16]byte\n"
+            "\tSizes [2][4]int\n"
+            "}{}\n"
+        )
+        types = {s.name: s.value_type for s in self.analyzer.analyze_source("lit.go", src).symbols}
+        self.assertEqual(types, {"Split": "func() (ss, ct []byte)",
+                                 "Header": "struct{ Magic [16]byte; Sizes [2][4]int }"})
+        # 无法推断时保留初始化表达式
+        self.assertEqual(by_name["Client"].value_expr, "http.NewClient()")
+        self.assertEqual(by_name["Zero"].value_expr, "")
+
+    def test_merge(self):
+        first = self.analyzer.analyze_source("a.go", "package p\n\nimport \"fmt\"\n\nfunc Shared() {}\n\nfunc OnlyA() {}\n")
+        again = self.analyzer.analyze_source("a.go", "package p\n\nimport \"os\"\n\nfunc Shared() {}\n")
+        other = self.analyzer.analyze_source("b.go", "package p\n\nfunc Shared() {}\n")
+
+        merged = first.merge(again, other)
+        self.assertEqual(
+            [(s.file, s.name) for s in merged.symbols],
+            [("a.go", "Shared"), ("a.go", "OnlyA"), ("b.go", "Shared")],
+        )
+        self.assertEqual(merged.imports, ["fmt", "os"])
+        self.assertEqual([d["file"] for d in merged.to_dict()["symbols"]], ["a.go", "a.go", "b.go"])
+        # 原结果不受影响
+        self.assertEqual(len(first.symbols), 2)
+
+        duplicated = replace(first, symbols=first.symbols + first.symbols)
+        self.assertEqual([s.name for s in duplicated.dedup().symbols], ["Shared", "OnlyA"])
+
+    def test_package_clause(self):
+        result = self.analyzer.analyze(self.codes_dir / "demo.go")
+        self.assertEqual((result.package, result.package_line, result.package_column), ("main", 1, 1))
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertEqual((restored.package_line, restored.package_column), (1, 1))
+
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "foo.go").write_text("// Package foo 文档。\npackage foo\n")
+            (root / "foo_test.go").write_text("package foo_test\n")
+            (root / "bar.go").write_text("package foo\n")
+            results, _ = self.analyzer.analyze_dir(root, workers=1)
+            self.assertEqual(results["foo.go"].package_line, 2)
+            groups = group_by_package(results.values())
+            self.assertEqual({name: [f.path for f in files] for name, files in groups.items()},
+                             {"foo": ["bar.go", "foo.go"], "foo_test": ["foo_test.go"]})
+
+    def test_promoted_methods(self):
+        src = (
+            "package p\n"
+            "\n"
+            "type Base struct{ ID int }\n"
+            "\n"
+            "func (Base) Hello() {}\n"
+            "func (*Base) Save() {}\n"
+            "func (Base) Name() string { return \"\" }\n"
+            "\n"
+            "type Logger struct{}\n"
+            "\n"
+            "func (*Logger) Log() {}\n"
+            "func (*Logger) Hello() {}\n"
+            "\n"
+            "type Inner struct{ Logger }\n"
+            "\n"
+            "type Derived struct {\n"
+            "\tBase\n"
+            "\t*Inner\n"
+            "}\n"
+            "\n"
+            "func (Derived) Name() string { return \"derived\" }\n"
+        )
+        groups, _ = group_by_type(self.analyzer.analyze_source("p.go", src).symbols)
+        promoted = {p.method.name: p for p in groups["Derived"].promoted}
+
+        # Name 被 Derived 自己的方法遮蔽；Base.Hello 比 Inner.Logger.Hello 浅，遮蔽后者
+        self.assertEqual(sorted(promoted), ["Hello", "Log", "Save"])
+        self.assertEqual(promoted["Hello"].method.receiver, "Base")
+        self.assertEqual(promoted["Hello"].path, ["Base"])
+        self.assertEqual(promoted["Log"].path, ["Inner", "Logger"])
+        # 值嵌入的 Base 的指针方法只属于 *Derived；经 *Inner 嵌入的不受限
+        self.assertTrue(promoted["Save"].pointer_only)
+        self.assertFalse(promoted["Log"].pointer_only)
+        self.assertEqual([p.method.name for p in groups["Inner"].promoted], ["Hello", "Log"])
+        self.assertEqual(groups["Base"].promoted, [])
+
+        # 同一深度的同名方法有歧义，都不提升
+        ambiguous = src + "\ntype Both struct {\n\tBase\n\tLogger\n}\n"
+        groups, _ = group_by_type(self.analyzer.analyze_source("p.go", ambiguous).symbols)
+        self.assertEqual([p.method.name for p in groups["Both"].promoted], ["Log", "Name", "Save"])
+
+    def test_index_rows(self):
+        path = self.codes_dir / "demo.go"
+        rows = list(self.analyzer.analyze(path).index_rows())
+        method = next(r for r in rows if r.name == "Method")
+        self.assertEqual(method._fields, ("id", "file", "package", "kind", "name", "qualified_name",
+                                          "signature", "doc", "start_line", "end_line"))
+        self.assertEqual((method.file, method.package, method.kind, method.qualified_name),
+                         (str(path), "main", "method", "main.MyStruct.Method"))
+        self.assertEqual(method.signature, "func (s *MyStruct) Method()")
+
+        # 相同输入两次解析得到相同的 id，且文件内互不相同
+        again = list(self.analyzer.analyze(path).index_rows())
+        self.assertEqual([r.id for r in rows], [r.id for r in again])
+        self.assertEqual(len({r.id for r in rows}), len(rows))
+        # 换一个文件路径 id 随之改变
+        moved = self.analyzer.analyze_source("other/demo.go", path.read_bytes())
+        self.assertNotEqual(next(moved.index_rows()).id, rows[0].id)
+
+    def test_multi_name_specs(self):
+        src = (
+            "package p\n"
+            "\n"
+            "var a, b = 1, \"two\"\n"
+            "\n"
+            "var (\n"
+            "\tx int\n"
+            "\ty, z string\n"
+            "\tr, err = open()\n"
+            ")\n"
+            "\n"
+            "type (\n"
+            "\tA int\n"
+            "\tB string\n"
+            ")\n"
+            "\n"
+            "const (\n"
+            "\tKB, MB = 1 << (10 * (iota + 1)), 1 << (20 * (iota + 1))\n"
+            "\tGB, TB\n"
+            ")\n"
+        )
+        symbols = self.analyzer.analyze_source("p.go", src).symbols
+        self.assertEqual(
+            [(s.name, s.kind, s.line, s.column, s.value_type) for s in symbols],
+            [("a", Kind.VAR, 3, 1, "int"), ("b", Kind.VAR, 3, 8, "string"),
+             ("x", Kind.VAR, 6, 2, "int"), ("y", Kind.VAR, 7, 2, "string"), ("z", Kind.VAR, 7, 5, "string"),
+             ("r", Kind.VAR, 8, 2, ""), ("err", Kind.VAR, 8, 5, ""),
+             ("A", Kind.TYPE, 12, 2, ""), ("B", Kind.TYPE, 13, 2, ""),
+             ("KB", Kind.CONST, 17, 2, "int"), ("MB", Kind.CONST, 17, 6, "int"),
+             ("GB", Kind.CONST, 18, 2, "int"), ("TB", Kind.CONST, 18, 6, "int")],
+        )
+        by_name = {s.name: s for s in symbols}
+        self.assertEqual((by_name["a"].value_expr, by_name["b"].value_expr), ("1", '"two"'))
+        self.assertEqual(by_name["err"].value_expr, "open()")
+        self.assertEqual([by_name[n].value for n in ("KB", "MB", "GB", "TB")],
+                         [str(1 << 10), str(1 << 20), str(1 << 20), str(1 << 40)])
+        # 每个名称的原文从自己开始，到 spec 结束
+        self.assertEqual(by_name["a"].source(), 'var a, b = 1, "two"')
+        self.assertEqual(by_name["b"].source(), 'b = 1, "two"')
+        self.assertEqual(by_name["z"].source(), "z string")
+
+    def test_slice_lines(self):
+        src = (
+            "package p\n"
+            "\n"
+            "// Add 返回两数之和。\n"
+            "func Add(a, b int) int {\n"
+            "\treturn a + b\n"
+            "}\n"
+            "\n"
+            "var X = 1\n"
+        )
+        result = self.analyzer.analyze_source("p.go", src)
+        self.assertEqual(result.slice_lines(4, 5), "func Add(a, b int) int {\n\treturn a + b")
+        self.assertEqual(result.slice_lines(8, 8), "var X = 1")
+        # 扩大到相交声明的完整范围（含文档注释）
+        self.assertEqual(result.slice_lines(5, 5, expand=True), result.lookup("Add").source())
+        self.assertEqual(result.slice_lines(2, 2, expand=True), "")
+
+        for start, end in ((0, 1), (3, 9), (5, 4)):
+            with self.assertRaises(ValueError):
+                result.slice_lines(start, end)
+        with self.assertRaises(ValueError):
+            FileAnalysis.from_json(result.to_json()).slice_lines(1, 1)
+
+    def test_directive_comments(self):
+        src = b"""package p
+
+// F does x.
+//go:noinline
+func F() {}
+
+//go:generate stringer -type=Kind
+//export G
+func G() {}
+
+// H keeps this line: go:embed is not a directive here.
+//
+//line h.go:10
+func H() {}
+
+type T struct {
+	A int //go:notinheap
+	B int // b comment
+}
+"""
+        result = self.analyzer.analyze_source("p.go", src)
+        docs = {s.name: s.docstring for s in result.symbols}
+        self.assertEqual(docs["F"], "F does x.")
+        self.assertEqual(docs["G"], "")
+        self.assertEqual(docs["H"], "H keeps this line: go:embed is not a directive here.")
+        # 指令行仍属于注释组，原文从注释组开始
+        self.assertEqual(result.lookup("G").source(), "//go:generate stringer -type=Kind\n//export G\nfunc G() {}")
+        fields = {f.name: f.comment for f in result.lookup("T").fields}
+        self.assertEqual(fields, {"A": "", "B": "b comment"})
+
+    def test_unicode_line_separators(self):
+        # 只有 \n 分行：字符串中的 \u2028 与独占一行的换页符不影响行号与文档
+        src = 'package p\n\nvar s = "a\u2028b"\n\n// F doc\nfunc F() {}\n'
+        result = self.analyzer.analyze_source("p.go", src)
+        self.assertEqual(result.lines, 6)
+        f = result.lookup("F")
+        self.assertEqual((f.line, f.docstring), (6, "F doc"))
+        self.assertEqual(f.source(), "// F doc\nfunc F() {}")
+
+        src = "package p\n\f\n// G doc\nfunc G() {}\n"
+        result = self.analyzer.analyze_source("p.go", src)
+        self.assertEqual(result.lines, 4)
+        self.assertEqual(result.lookup("G").docstring, "G doc")
+
+    def test_crlf_and_bom(self):
+        lf = self.analyzer.analyze(self.codes_dir / "demo.go")
+        crlf = self.analyzer.analyze(self.codes_dir / "demo_crlf_bom.go")
+        self.assertTrue(crlf.source.startswith("\ufeffpackage main\r\n"))
+        self.assertFalse(crlf.partial)
+        self.assertEqual((crlf.package, crlf.package_line, crlf.package_column), ("main", 1, 1))
+
+        # 位置与 LF 版本一致：列号不受 BOM 与 \r 影响
+        def positions(result):
+            return [(s.key, s.line, s.column, s.end_line, s.end_column) for s in result.symbols]
+        self.assertEqual(positions(crlf), positions(lf))
+        self.assertEqual([s.docstring for s in crlf.symbols], [s.docstring for s in lf.symbols])
+        self.assertEqual([m.docstring for m in crlf.lookup("MyInterface").methods],
+                         [m.docstring for m in lf.lookup("MyInterface").methods])
+
+        # 原文保留 \r\n
+        for a, b in zip(lf.symbols, crlf.symbols):
+            self.assertEqual(b.source(), a.source().replace("\n", "\r\n"))
+            self.assertEqual(b.source(doc=False), a.source(doc=False).replace("\n", "\r\n"))
+        self.assertEqual(crlf.slice_lines(1, 3), 'package main\r\n\r\nimport "fmt"')
+
+    def test_deprecated(self):
+        src = (
+            "package p\n"
+            "\n"
+            "// OldSum 返回两数之和。\n"
+            "//\n"
+            "// Deprecated: 请改用 Sum，\n"
+            "// 它支持任意个参数。\n"
+            "//\n"
+            "// 此段不属于弃用说明。\n"
+            "func OldSum(a, b int) int { return a + b }\n"
+            "\n"
+            "// Mention 的文档提到 Deprecated: 但不在段首。\n"
+            "func Mention() {}\n"
+        )
+        result = self.analyzer.analyze_source("p.go", src)
+        old = result.lookup("OldSum")
+        self.assertTrue(old.is_deprecated)
+        self.assertEqual(old.deprecation_note, "请改用 Sum， 它支持任意个参数。")
+        self.assertFalse(result.lookup("Mention").is_deprecated)
+        restored = FileAnalysis.from_json(result.to_json())
+        self.assertEqual(restored.lookup("OldSum").deprecation_note, old.deprecation_note)
+
+    def test_fingerprint(self):
+        src = (
+            "package p\n"
+            "\n"
+            "// Sum 求和。\n"
+            "func Sum(values []int) (total int) {\n"
+            "\tfor _, v := range values {\n"
+            "\t\ttotal += v // 累加\n"
+            "\t}\n"
+            "\treturn total\n"
+            "}\n"
+            "\n"
+            "func Add(xs []int) (acc int) { for _, x := range xs { acc += x }; return acc }\n"
+            "\n"
+            "func Product(values []int) (total int) {\n"
+            "\tfor _, v := range values {\n"
+            "\t\ttotal *= v\n"
+            "\t}\n"
+            "\treturn total\n"
+            "}\n"
+            "\n"
+            "func Count(values []int) (total int) {\n"
+            "\tfor range values {\n"
+            "\t\ttotal = inc(total)\n"
+            "\t}\n"
+            "\treturn total\n"
+            "}\n"
+        )
+        result = self.analyzer.analyze_source("p.go", src)
+        sums = result.lookup("Sum"), result.lookup("Add")
+        # 只有局部名称、函数名、格式与注释不同
+        self.assertEqual(sums[0].fingerprint, sums[1].fingerprint)
+        self.assertEqual(len(sums[0].fingerprint), 32)
+        self.assertNotEqual(sums[0].fingerprint, result.lookup("Product").fingerprint)
+        # 与逐字比较的原文哈希不同
+        self.assertNotEqual(body_hash(sums[0]), body_hash(sums[1]))
+
+        renamed_call = src.replace("inc(total)", "dec(total)")
+        self.assertNotEqual(self.analyzer.analyze_source("p.go", renamed_call).lookup("Count").fingerprint,
+                            result.lookup("Count").fingerprint)
+
+    def test_json_rejects_newer_schema(self):
+        data = self.analyzer.analyze(self.codes_dir / "demo.go").to_dict()
+        data["schema_version"] = SCHEMA_VERSION + 1
+        with self.assertRaises(ValueError):
+            FileAnalysis.from_dict(data)
+
+
+if __name__ == "__main__":
+    unittest.main()
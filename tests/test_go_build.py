@@ -0,0 +1,87 @@
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.parsers.go import GoAnalyzer
+from analyzer.parsers.go_build import BuildContext, read_constraints
+
+LINUX = BuildContext(goos="linux", goarch="amd64")
+WINDOWS = BuildContext(goos="windows", goarch="amd64")
+
+
+class TestGoBuild(unittest.TestCase):
+    def test_file_name(self):
+        self.assertTrue(LINUX.match_file_name("main.go"))
+        self.assertTrue(LINUX.match_file_name("linux.go"))
+        self.assertTrue(LINUX.match_file_name("sys_linux.go"))
+        self.assertFalse(LINUX.match_file_name("sys_windows.go"))
+        self.assertFalse(LINUX.match_file_name("sys_windows_test.go"))
+        self.assertTrue(LINUX.match_file_name("sys_linux_amd64.go"))
+        self.assertFalse(LINUX.match_file_name("sys_linux_arm64.go"))
+        self.assertFalse(LINUX.match_file_name("asm_arm64.go"))
+        self.assertTrue(BuildContext(goos="android").match_file_name("sys_linux.go"))
+        self.assertTrue(LINUX.match_file_name("sys_unknown.go"))
+
+    def test_go_build_expression(self):
+        def match(expr, ctx=LINUX):
+            return ctx.match("x.go", f"//go:build {expr}\n\npackage x\n")
+
+        self.assertTrue(match("linux"))
+        self.assertFalse(match("windows"))
+        self.assertTrue(match("linux && amd64"))
+        self.assertTrue(match("darwin || (linux && !arm64)"))
+        self.assertFalse(match("!unix"))
+        self.assertTrue(match("go1.18"))
+        self.assertFalse(match("go1.99"))
+        self.assertFalse(match("integration"))
+        self.assertTrue(match("integration", BuildContext(tags=["integration"])))
+        with self.assertRaises(ValueError):
+            match("linux &&")
+        # 文件开头的 BOM 与 \r\n 换行不影响约束识别
+        self.assertFalse(LINUX.match("x.go", "\ufeff//go:build windows\r\n\r\npackage x\r\n"))
+
+    def test_plus_build(self):
+        # 行内空格为或、逗号为与，多行之间为与
+        src = "// +build linux darwin\n// +build amd64,!cgo\n\npackage x\n"
+        self.assertTrue(LINUX.match("x.go", src))
+        self.assertFalse(WINDOWS.match("x.go", src))
+        self.assertFalse(BuildContext(cgo=True).match("x.go", src))
+
+        # 未与 package 子句隔开空行的 +build 不生效
+        self.assertEqual(read_constraints("// +build windows\npackage x\n"), (None, []))
+        # //go:build 优先于 +build
+        src = "//go:build windows\n// +build linux\n\npackage x\n"
+        self.assertFalse(LINUX.match("x.go", src))
+        self.assertTrue(WINDOWS.match("x.go", src))
+        # package 之后的约束被忽略
+        self.assertTrue(LINUX.match("x.go", "package x\n\n//go:build windows\n"))
+
+    def test_analyze_dir(self):
+        with tempfile.TemporaryDirectory() as tmp:
+            root = Path(tmp)
+            (root / "main.go").write_text("package x\n\nfunc Common() {}\n")
+            (root / "console.go").write_text("//go:build windows\n\npackage x\n\nfunc Console() {}\n")
+            (root / "legacy.go").write_text("// +build windows\n\npackage x\n\nfunc Legacy() {}\n")
+            (root / "path_windows.go").write_text("package x\n\nfunc Drive() {}\n")
+            (root / "epoll_linux.go").write_text("package x\n\nfunc Epoll() {}\n")
+            (root / "bad.go").write_text("//go:build (linux\n\npackage x\n")
+
+            analyzer = GoAnalyzer()
+            results, errors = analyzer.analyze_dir(root, build_context=LINUX)
+            self.assertEqual(sorted(results), ["epoll_linux.go", "main.go"])
+            self.assertEqual([e.path for e in errors], ["bad.go"])
+
+            results, _ = analyzer.analyze_dir(root, build_context=WINDOWS)
+            self.assertEqual(sorted(results), ["console.go", "legacy.go", "main.go", "path_windows.go"])
+
+            # 不提供构建上下文时不过滤
+            results, _ = analyzer.analyze_dir(root)
+            self.assertEqual(len(results), 6)
+
+
+if __name__ == "__main__":
+    unittest.main()
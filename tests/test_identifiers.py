@@ -0,0 +1,45 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.identifiers import split_identifier, tokenize
+
+CASES = [
+    ("GlobalVar", ["global", "var", "globalvar"]),
+    ("globalVar", ["global", "var", "globalvar"]),
+    ("HTTPServer", ["http", "server", "httpserver"]),
+    ("ServeHTTP", ["serve", "http", "servehttp"]),
+    ("getHTTPResponseCode", ["get", "http", "response", "code", "gethttpresponsecode"]),
+    ("userID", ["user", "id", "userid"]),
+    ("ID", ["id"]),
+    ("URL", ["url"]),
+    ("Base64Encode", ["base", "64", "encode", "base64encode"]),
+    ("max_retry", ["max", "retry"]),
+    ("MAX_RETRY_COUNT", ["max", "retry", "count"]),
+    ("_hidden", ["hidden"]),
+    ("parse_HTTPHeader", ["parse", "http", "header", "httpheader"]),
+    ("x", ["x"]),
+    ("", []),
+    ("名称", ["名称"]),
+]
+
+
+class TestIdentifiers(unittest.TestCase):
+    def test_split_identifier(self):
+        for ident, expected in CASES:
+            with self.subTest(ident=ident):
+                self.assertEqual(split_identifier(ident), expected)
+
+    def test_without_whole(self):
+        self.assertEqual(split_identifier("HTTPServer", keep_whole=False), ["http", "server"])
+
+    def test_tokenize_text(self):
+        self.assertEqual(tokenize("// ParseConfig reads a file."),
+                         ["parse", "config", "parseconfig", "reads", "a", "file"])
+
+
+if __name__ == "__main__":
+    unittest.main()
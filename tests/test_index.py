@@ -0,0 +1,95 @@
+import tempfile
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.index import Index
+from analyzer.parsers.go_build import BuildContext
+
+FILES = {
+    "store/types.go": "package store\n\ntype Item struct{}\n\ntype Cache struct{ items []Item }\n",
+    "store/cache.go": "package store\n\nfunc (c *Cache) Get() Item { return Item{} }\n",
+    "store/load.go": "package store\n\nimport \"os\"\n\nfunc Load() *Cache { os.Exit(0); return nil }\n",
+    "api/api.go": "package api\n\nfunc Serve() {}\n",
+}
+
+
+class TestIndex(unittest.TestCase):
+    def setUp(self):
+        self.tmp = tempfile.TemporaryDirectory()
+        root = Path(self.tmp.name)
+        for rel, text in FILES.items():
+            (root / rel).parent.mkdir(parents=True, exist_ok=True)
+            (root / rel).write_text(text)
+        self.index, errors = Index.build(root)
+        self.assertEqual(errors, [])
+
+    def tearDown(self):
+        self.tmp.cleanup()
+
+    def test_build(self):
+        self.assertEqual(sorted(self.index.files), sorted(FILES))
+        groups, orphans = self.index.group_by_type("store")
+        self.assertEqual([m.name for m in groups["Cache"].methods], ["Get"])
+        self.assertEqual(orphans, [])
+        # 跨文件的包内依赖与外部依赖
+        self.assertEqual(self.index.dependencies("store/cache.go")["Cache.Get"].internal, ["Cache", "Item"])
+        self.assertEqual(self.index.dependencies("store/load.go")["Load"].internal, ["Cache"])
+        self.assertEqual(self.index.dependencies("store/load.go")["Load"].external, ["os"])
+        # 包级目录跨文件嵌套方法
+        self.assertEqual(self.index.outline("store"),
+                         "types:\n  Item (L3)\n  Cache (L5)\n    Get (L3)\nfuncs: Load (L5)")
+
+    def test_slice(self):
+        self.assertEqual(self.index.slice("store/types.go", 3, 3), "type Item struct{}")
+        with self.assertRaises(ValueError):
+            self.index.slice("missing.go", 1, 1)
+
+    def test_update(self):
+        untouched = self.index.files["api/api.go"]
+        load_deps = self.index.dependencies("store/load.go")
+
+        # 给 Cache 增加方法：分组更新，其他文件的结果与依赖边保持原对象
+        self.index.update("store/cache.go",
+                          "package store\n\nfunc (c *Cache) Get() Item { return Item{} }\n\nfunc (c *Cache) Len() int { return 0 }\n")
+        groups, _ = self.index.group_by_type("store")
+        self.assertEqual([m.name for m in groups["Cache"].methods], ["Get", "Len"])
+        self.assertIs(self.index.files["api/api.go"], untouched)
+        self.assertIs(self.index.dependencies("store/load.go"), load_deps)
+
+        # 重命名类型：引用旧名称的其他文件的依赖边被重算
+        self.index.update("store/types.go", b"package store\n\ntype Item struct{}\n\ntype Store struct{}\n")
+        self.assertEqual(self.index.dependencies("store/load.go")["Load"].internal, [])
+        self.assertEqual(self.index.dependencies("store/cache.go")["Cache.Get"].internal, ["Item"])
+        _, orphans = self.index.group_by_type("store")
+        self.assertEqual([m.name for m in orphans], ["Get", "Len"])
+
+        # 新文件
+        self.index.update("api/health.go", "package api\n\nfunc Health() { Serve() }\n")
+        self.assertEqual(self.index.dependencies("api/health.go")["Health"].internal, ["Serve"])
+
+    def test_remove(self):
+        self.index.remove("store/types.go")
+        self.assertNotIn("store/types.go", self.index.files)
+        self.assertEqual(self.index.dependencies("store/cache.go")["Cache.Get"].internal, [])
+        _, orphans = self.index.group_by_type("store")
+        self.assertEqual([m.name for m in orphans], ["Get"])
+        self.assertNotIn("Item", {s.name for s in self.index.symbols()})
+        # 重复删除不报错
+        self.index.remove("store/types.go")
+
+    def test_build_context(self):
+        index = Index(build_context=BuildContext(goos="linux"))
+        index.update("sys_linux.go", "package x\n\nfunc A() {}\n")
+        index.update("sys_windows.go", "package x\n\nfunc B() {}\n")
+        self.assertEqual(sorted(index.files), ["sys_linux.go"])
+        # 加上不满足的约束后从索引中移除
+        index.update("sys_linux.go", "//go:build ignore\n\npackage x\n")
+        self.assertEqual(index.files, {})
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,82 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.packing import pack
+from analyzer.parsers.go import GoAnalyzer
+from analyzer.ranking import rank
+
+SOURCE = '''package store
+
+// Config holds the settings.
+type Config struct {
+	Path string
+}
+
+// ParseConfig reads the configuration file.
+func ParseConfig(path string) (*Config, error) {
+	return &Config{Path: path}, nil
+}
+
+// OpenDatabase connects to the database described by the configuration.
+func OpenDatabase(cfg *Config) error {
+	return nil
+}
+
+// Render writes the page.
+func Render() {}
+'''
+
+
+class TestPacking(unittest.TestCase):
+    def setUp(self):
+        self.analysis = GoAnalyzer().analyze_source("store.go", SOURCE)
+        self.scored = rank("parse configuration", self.analysis.symbols)
+        self.cost = {s.name: s.token_count() for s in self.analysis.symbols}
+
+    def test_pack_in_score_order(self):
+        budget = self.cost["ParseConfig"] + self.cost["OpenDatabase"]
+        packed = pack(self.scored, budget)
+        self.assertEqual([s.symbol.name for s in packed.symbols], ["ParseConfig", "OpenDatabase"])
+        self.assertEqual(packed.tokens, budget)
+
+        # 少一个 token 就放不下第二个符号；得分为 0 的 Render 即使放得下也不入选
+        packed = pack(self.scored, budget - 1)
+        self.assertEqual([s.symbol.name for s in packed.symbols], ["ParseConfig"])
+        self.assertEqual(packed.tokens, self.cost["ParseConfig"])
+
+    def test_pack_unsorted_input(self):
+        # 输入顺序不影响结果：低分符号不会先占用预算
+        budget = self.cost["ParseConfig"] + self.cost["OpenDatabase"]
+        packed = pack(list(reversed(self.scored)), budget)
+        self.assertEqual([s.symbol.name for s in packed.symbols], ["ParseConfig", "OpenDatabase"])
+        self.assertEqual(packed, pack(self.scored, budget))
+
+    def test_pack_with_dependencies(self):
+        graph = self.analysis.dependencies()
+        budget = self.cost["ParseConfig"] + self.cost["OpenDatabase"] + self.cost["Config"] - 1
+        packed = pack(self.scored, budget, graph=graph)
+
+        # Config 先于依赖它的 ParseConfig 加入；OpenDatabase 超出预算
+        self.assertEqual([s.symbol.name for s in packed.symbols], ["Config", "ParseConfig"])
+        self.assertEqual(packed.tokens, self.cost["Config"] + self.cost["ParseConfig"])
+        self.assertLessEqual(packed.tokens, budget)
+        self.assertTrue(packed.text().startswith("// Config holds the settings."))
+
+        # 符号连同依赖放不下时整体不加入
+        packed = pack(self.scored, self.cost["ParseConfig"], graph=graph)
+        self.assertEqual(packed.symbols, [])
+        self.assertEqual(packed.tokens, 0)
+
+    def test_pack_rejects_invalid(self):
+        with self.assertRaises(ValueError):
+            pack(self.scored, -1)
+        with self.assertRaises(ValueError):
+            pack(self.scored, 100, model="unknown")
+
+
+if __name__ == "__main__":
+    unittest.main()
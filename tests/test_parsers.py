@@ -110,7 +110,7 @@ class TestParsers(unittest.TestCase):
         self.assertIn("Function", symbols)
         self.assertEqual(symbols["Function"], "function")
         self.assertIn("Method", symbols)  # Method on struct
-        self.assertEqual(symbols["Method"], "function")
+        self.assertEqual(symbols["Method"], "method")
 
     def test_rust_parser(self):
         path = self.codes_dir / "demo.rs"
@@ -0,0 +1,73 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.core import SymbolInfo
+from analyzer.parsers.go import GoAnalyzer
+from analyzer.ranking import RankWeights, rank, tokenize
+
+SOURCE = '''package store
+
+// OpenDatabase connects to the SQL database at dsn.
+func OpenDatabase(dsn string) (*DB, error) { return nil, nil }
+
+// ParseConfig reads the YAML configuration file.
+func ParseConfig(path string) (*Config, error) { return nil, nil }
+
+// Render writes the HTML template to w.
+func Render(w io.Writer, name string) error { return nil }
+
+// Config holds the settings loaded from the configuration file.
+type Config struct{}
+'''
+
+
+class TestRanking(unittest.TestCase):
+    def setUp(self):
+        self.symbols = GoAnalyzer().analyze_source("store.go", SOURCE).symbols
+
+    def test_tokenize(self):
+        self.assertEqual(tokenize("ParseHTTPRequest"), ["parse", "http", "request", "parsehttprequest"])
+        self.assertEqual(tokenize("max_retry  count"), ["max", "retry", "count"])
+
+    def test_rank(self):
+        top = rank("open database connection", self.symbols)
+        self.assertEqual(top[0].symbol.name, "OpenDatabase")
+        self.assertGreater(top[0].score, top[1].score)
+
+        top = rank("html template", self.symbols)
+        self.assertEqual(top[0].symbol.name, "Render")
+
+        # 不相关的查询得分全为 0，按源码位置排序
+        ranked = rank("kubernetes", self.symbols)
+        self.assertTrue(all(s.score == 0 for s in ranked))
+        self.assertEqual([s.symbol.name for s in ranked], ["OpenDatabase", "ParseConfig", "Render", "Config"])
+
+    def test_weights(self):
+        # 默认权重下 ParseConfig 的名称与签名都含 config；只看名称时更短的 Config 更相关
+        self.assertEqual(rank("config", self.symbols)[0].symbol.name, "ParseConfig")
+        name_only = RankWeights(name=1, doc=0, signature=0)
+        self.assertEqual(rank("config", self.symbols, name_only)[0].symbol.name, "Config")
+        # 权重为 0 的字段不参与打分
+        self.assertTrue(all(s.score == 0 for s in rank("yaml", self.symbols, name_only)))
+
+    def test_ties_break_by_position(self):
+        symbols = [SymbolInfo(name="Get", type="function", line=9, column=1),
+                   SymbolInfo(name="Get", type="function", line=3, column=5),
+                   SymbolInfo(name="Get", type="function", line=3, column=1)]
+        ranked = rank("get", symbols)
+        self.assertEqual([(s.symbol.line, s.symbol.column) for s in ranked], [(3, 1), (3, 5), (9, 1)])
+
+        # 合并多个文件的符号时先按文件排列
+        symbols = [SymbolInfo(name="Get", type="function", line=1, file="b.go"),
+                   SymbolInfo(name="Get", type="function", line=7, file="a.go"),
+                   SymbolInfo(name="Get", type="function", line=2, file="a.go")]
+        ranked = rank("get", symbols)
+        self.assertEqual([(s.symbol.file, s.symbol.line) for s in ranked], [("a.go", 2), ("a.go", 7), ("b.go", 1)])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,44 @@
+import unittest
+import sys
+from pathlib import Path
+
+# Add scripts directory to path
+sys.path.append(str(Path(__file__).parent.parent / "code-context-analyzer" / "scripts"))
+
+from analyzer.parsers.go import GoAnalyzer
+from analyzer.tokens import count_tokens, tokenizer_family
+
+
+class TestTokens(unittest.TestCase):
+    def test_count_tokens(self):
+        # 常见短单词各 1 个 token，长标识符按 6 字符一段
+        self.assertEqual(count_tokens("hello world"), 2)
+        self.assertEqual(count_tokens("GenericFunc"), 2)
+        # 数字按 3 位一组
+        self.assertEqual(count_tokens("1234567"), 3)
+        self.assertEqual(count_tokens("func Function(a int) int"), 7)
+        self.assertEqual(count_tokens(""), 0)
+
+        # 空白兜底：按单词计数
+        self.assertEqual(count_tokens("func Function(a int) int", "whitespace"), 4)
+
+        # 模型名映射与确定性
+        self.assertEqual(tokenizer_family("gpt-4-turbo"), "cl100k")
+        text = "func (s *MyStruct) Method() {\n\tfmt.Println(\"Method\")\n}\n"
+        self.assertEqual(count_tokens(text, "gpt-4"), count_tokens(text, "cl100k"))
+        with self.assertRaises(ValueError):
+            count_tokens(text, "unknown-model")
+
+    def test_symbol_token_count(self):
+        codes_dir = Path(__file__).parent / "codes"
+        result = GoAnalyzer().analyze(codes_dir / "demo.go")
+        costs = {s.name: s.token_count() for s in result.symbols}
+        self.assertTrue(all(c > 0 for c in costs.values()))
+        self.assertGreater(costs["GenericFunc"], costs["Function"])
+        self.assertEqual(result.token_count(), sum(costs.values()))
+        self.assertEqual(result.token_count("whitespace"),
+                         sum(s.token_count("whitespace") for s in result.symbols))
+
+
+if __name__ == "__main__":
+    unittest.main()